@@ -1,5 +1,7 @@
 package v1alpha1
 
+import "time"
+
 type EnvoyConfig struct {
 	Name string `yaml:"name"`
 	Spec `yaml:"spec"`
@@ -12,19 +14,418 @@ type Spec struct {
 }
 
 type Listener struct {
-	Name     string  `yaml:"name"`
-	Address  string  `yaml:"address"`
-	Port     uint32  `yaml:"port"`
-	Routes   []Route `yaml:"routes"`
-	CertFile string  `yaml:"certFile"`
-	KeyFile  string  `yaml:"keyFile"`
+	Name    string `yaml:"name"`
+	Address string `yaml:"address"`
+	Port    uint32 `yaml:"port"`
+	// Protocol defaults to http when unset
+	Protocol ListenerProtocol `yaml:"protocol"`
+	// Routes are served from a catch-all virtual host matching any domain
+	Routes       []Route       `yaml:"routes"`
+	VirtualHosts []VirtualHost `yaml:"virtualHosts"`
+	// HeaderManipulation applies to all routes of the listener
+	HeaderManipulation `yaml:",inline"`
+	// TLS terminates TLS on the listener when set
+	TLS *DownstreamTLS `yaml:"tls"`
+	// Deprecated: CertFile and KeyFile are kept for existing configs and are
+	// the same as a TLS block with only those set
+	CertFile string `yaml:"certFile"`
+	KeyFile  string `yaml:"keyFile"`
+	// Cluster and WeightedClusters are the upstream of tcp and
+	// tls-passthrough listeners, which have no routes
+	Cluster          string            `yaml:"cluster"`
+	WeightedClusters []WeightedCluster `yaml:"weightedClusters"`
+	// ExtAuthz authorizes the requests of http listeners when enabled
+	ExtAuthz *ExtAuthzFilter `yaml:"extAuthz"`
+	// GlobalRateLimit sends the descriptors produced by the RateLimits of the
+	// routes to a rate limit service
+	GlobalRateLimit *GlobalRateLimit `yaml:"globalRateLimit"`
+	// AccessLogs default to Envoy's default format written to /dev/stdout
+	AccessLogs []AccessLog `yaml:"accessLogs"`
+	// FilterChains serve their own certificate and routes to clients whose
+	// SNI matches one of their ServerNames. Routes, VirtualHosts, TLS and
+	// the upstream clusters above make up the default chain for all other
+	// connections.
+	FilterChains []FilterChain `yaml:"filterChains"`
+}
+
+// ListenerProtocol selects how a listener handles its connections. http
+// terminates HTTP and routes requests, tcp proxies connections to a cluster,
+// optionally terminating TLS, and tls-passthrough proxies TLS connections
+// based on their SNI without terminating them.
+type ListenerProtocol string
+
+const (
+	HTTP           ListenerProtocol = "http"
+	TCP            ListenerProtocol = "tcp"
+	TLSPassthrough ListenerProtocol = "tls-passthrough"
+)
+
+// ExtAuthzFilter asks the authorization service in Cluster whether a request
+// may be routed. The service implements the gRPC
+// envoy.service.auth.v3.Authorization API unless HTTPService is set.
+type ExtAuthzFilter struct {
+	// Enabled defaults to true, so that only an explicit false turns off
+	// authorization
+	Enabled *bool  `yaml:"enabled"`
+	Cluster string `yaml:"cluster"`
+	// Timeout defaults to 200ms when unset
+	Timeout time.Duration `yaml:"timeout"`
+	// FailureModeAllow lets requests through when the service is unavailable
+	FailureModeAllow bool `yaml:"failureModeAllow"`
+	// ClearRouteCache routes the request again after the service added
+	// headers to it
+	ClearRouteCache bool                 `yaml:"clearRouteCache"`
+	IncludeBody     *ExtAuthzBody        `yaml:"includeBody"`
+	HTTPService     *ExtAuthzHTTPService `yaml:"httpService"`
+}
+
+// ExtAuthzBody buffers up to MaxBytes of the request body and sends them to
+// the authorization service. Larger requests are rejected with a 413 unless
+// AllowPartialMessage is set.
+type ExtAuthzBody struct {
+	MaxBytes            uint32 `yaml:"maxBytes"`
+	AllowPartialMessage bool   `yaml:"allowPartialMessage"`
+}
+
+// ExtAuthzHTTPService sends the authorization check as a plain HTTP request
+// to PathPrefix followed by the original path. Unlike gRPC services, which
+// get all request headers, only the Host, Method, Path and Content-Length
+// headers plus AllowedHeaders are forwarded.
+type ExtAuthzHTTPService struct {
+	PathPrefix     string   `yaml:"pathPrefix"`
+	AllowedHeaders []string `yaml:"allowedHeaders"`
+}
+
+// GlobalRateLimit checks requests against the descriptors configured for
+// Domain in the envoy.service.ratelimit.v3.RateLimitService served by Cluster.
+type GlobalRateLimit struct {
+	Domain  string `yaml:"domain"`
+	Cluster string `yaml:"cluster"`
+	// Timeout defaults to 20ms when unset
+	Timeout time.Duration `yaml:"timeout"`
+	// FailureModeDeny rejects requests when the service is unavailable
+	FailureModeDeny bool `yaml:"failureModeDeny"`
+}
+
+// AccessLog writes an entry per request, or per connection on tcp and
+// tls-passthrough listeners, to exactly one of File or GRPC. Only the entries
+// matching Filter are logged when it is set.
+type AccessLog struct {
+	File   *FileAccessLog   `yaml:"file"`
+	GRPC   *GRPCAccessLog   `yaml:"grpc"`
+	Filter *AccessLogFilter `yaml:"filter"`
+}
+
+// FileAccessLog writes entries to Path in Envoy's default format unless one
+// of TextFormat or JSONFormat is set. Both take command operators such as
+// %RESPONSE_CODE% or %REQ(:PATH)%.
+type FileAccessLog struct {
+	Path       string            `yaml:"path"`
+	TextFormat string            `yaml:"textFormat"`
+	JSONFormat map[string]string `yaml:"jsonFormat"`
+}
+
+// GRPCAccessLog streams entries to the envoy.service.accesslog.v3.AccessLogService
+// served by Cluster. LogName defaults to the listener name.
+type GRPCAccessLog struct {
+	Cluster string `yaml:"cluster"`
+	LogName string `yaml:"logName"`
+}
+
+// AccessLogFilter logs the entries matching all of its set conditions.
+type AccessLogFilter struct {
+	StatusCode      *StatusCodeFilter      `yaml:"statusCode"`
+	Duration        *DurationFilter        `yaml:"duration"`
+	RuntimeSampling *RuntimeSamplingFilter `yaml:"runtimeSampling"`
+}
+
+// StatusCodeFilter compares the response code with Value. Op is one of ge, eq
+// or le.
+type StatusCodeFilter struct {
+	Op    string `yaml:"op"`
+	Value uint32 `yaml:"value"`
+}
+
+// DurationFilter compares the request duration with Value. Op is one of ge, eq
+// or le.
+type DurationFilter struct {
+	Op    string        `yaml:"op"`
+	Value time.Duration `yaml:"value"`
+}
+
+// RuntimeSamplingFilter logs Percent of the entries. The percentage can be
+// overridden at runtime through RuntimeKey.
+type RuntimeSamplingFilter struct {
+	RuntimeKey string `yaml:"runtimeKey"`
+	Percent    uint32 `yaml:"percent"`
+}
+
+type FilterChain struct {
+	Name               string         `yaml:"name"`
+	ServerNames        []string       `yaml:"serverNames"`
+	TLS                *DownstreamTLS `yaml:"tls"`
+	Routes             []Route        `yaml:"routes"`
+	VirtualHosts       []VirtualHost  `yaml:"virtualHosts"`
+	HeaderManipulation `yaml:",inline"`
+	Cluster            string            `yaml:"cluster"`
+	WeightedClusters   []WeightedCluster `yaml:"weightedClusters"`
 }
 
+// DownstreamTLS serves CertFile and KeyFile to clients. Client certificates
+// are validated against CAFile and, when set, must match one of
+// VerifySubjectAltNames or VerifyCertificateHashes (hex SHA-256 of the
+// certificate). MinVersion and MaxVersion are one of 1.0, 1.1, 1.2 or 1.3.
+type DownstreamTLS struct {
+	CertFile                 string   `yaml:"certFile"`
+	KeyFile                  string   `yaml:"keyFile"`
+	CAFile                   string   `yaml:"caFile"`
+	RequireClientCertificate bool     `yaml:"requireClientCertificate"`
+	VerifySubjectAltNames    []string `yaml:"verifySubjectAltNames"`
+	VerifyCertificateHashes  []string `yaml:"verifyCertificateHashes"`
+	MinVersion               string   `yaml:"minVersion"`
+	MaxVersion               string   `yaml:"maxVersion"`
+	CipherSuites             []string `yaml:"cipherSuites"`
+}
+
+type VirtualHost struct {
+	Name               string      `yaml:"name"`
+	Domains            []string    `yaml:"domains"`
+	Routes             []Route     `yaml:"routes"`
+	Cors               *CorsPolicy `yaml:"cors"`
+	HeaderManipulation `yaml:",inline"`
+}
+
+// HeaderManipulation adds and removes request and response headers. Header
+// values may use command operators such as %DOWNSTREAM_REMOTE_ADDRESS% or
+// %REQ(x-request-id)%. Headers of routes are applied before the ones of
+// their virtual host, which come before the ones of the listener.
+type HeaderManipulation struct {
+	RequestHeadersToAdd     []HeaderValue `yaml:"requestHeadersToAdd"`
+	RequestHeadersToRemove  []string      `yaml:"requestHeadersToRemove"`
+	ResponseHeadersToAdd    []HeaderValue `yaml:"responseHeadersToAdd"`
+	ResponseHeadersToRemove []string      `yaml:"responseHeadersToRemove"`
+}
+
+// HeaderValue replaces existing values of the header unless Append is set.
+type HeaderValue struct {
+	Name   string `yaml:"name"`
+	Value  string `yaml:"value"`
+	Append bool   `yaml:"append"`
+}
+
+// CorsPolicy answers CORS preflight requests and adds the CORS headers to the
+// responses of requests whose origin matches one of AllowOrigins. The policy
+// of a route replaces the one of its virtual host.
+type CorsPolicy struct {
+	AllowOrigins     []OriginMatcher `yaml:"allowOrigins"`
+	AllowMethods     []string        `yaml:"allowMethods"`
+	AllowHeaders     []string        `yaml:"allowHeaders"`
+	ExposeHeaders    []string        `yaml:"exposeHeaders"`
+	MaxAge           time.Duration   `yaml:"maxAge"`
+	AllowCredentials bool            `yaml:"allowCredentials"`
+}
+
+// OriginMatcher matches the Origin header on exactly one of Exact or Regex.
+type OriginMatcher struct {
+	Exact string `yaml:"exact"`
+	Regex string `yaml:"regex"`
+}
+
+// Route matches requests on exactly one of Prefix, Path or SafeRegex plus
+// any Headers and QueryParameters. It either forwards them to exactly one of
+// Cluster, WeightedClusters or the cluster named by the ClusterHeader
+// request header, or answers them itself with a Redirect or DirectResponse.
 type Route struct {
-	Name        string `yaml:"name"`
-	Prefix      string `yaml:"prefix"`
-	Header      string `yaml:"header"`
-	HostRewrite string `yaml:"hostRewrite"`
+	Name            string                  `yaml:"name"`
+	Prefix          string                  `yaml:"prefix"`
+	Path            string                  `yaml:"path"`
+	SafeRegex       string                  `yaml:"safeRegex"`
+	Headers         []HeaderMatcher         `yaml:"headers"`
+	QueryParameters []QueryParameterMatcher `yaml:"queryParameters"`
+	HostRewrite     string                  `yaml:"hostRewrite"`
+	// PrefixRewrite replaces the matched prefix, or path, before forwarding
+	PrefixRewrite string `yaml:"prefixRewrite"`
+	// RegexRewrite replaces the parts of the path matching its pattern
+	// before forwarding
+	RegexRewrite     *RegexRewrite     `yaml:"regexRewrite"`
+	Cluster          string            `yaml:"cluster"`
+	WeightedClusters []WeightedCluster `yaml:"weightedClusters"`
+	ClusterHeader    string            `yaml:"clusterHeader"`
+	// Redirect and DirectResponse answer the request from Envoy instead of
+	// forwarding it to a cluster
+	Redirect       *Redirect       `yaml:"redirect"`
+	DirectResponse *DirectResponse `yaml:"directResponse"`
+	// Timeout of the whole request. Envoy defaults to 15s when unset.
+	Timeout     time.Duration `yaml:"timeout"`
+	IdleTimeout time.Duration `yaml:"idleTimeout"`
+	RetryPolicy *RetryPolicy  `yaml:"retryPolicy"`
+	// HashPolicies pick the upstream host for RING_HASH and MAGLEV clusters
+	HashPolicies []HashPolicy `yaml:"hashPolicies"`
+	// DisableExtAuthz skips the ext_authz filter of the listener
+	DisableExtAuthz bool        `yaml:"disableExtAuthz"`
+	Cors            *CorsPolicy `yaml:"cors"`
+	// LocalRateLimit is enforced by each Envoy on its own
+	LocalRateLimit *LocalRateLimit `yaml:"localRateLimit"`
+	// RateLimits are checked against the global rate limit service of the
+	// listener
+	RateLimits []RateLimit `yaml:"rateLimits"`
+	// Fault injects delays and aborts into the requests of the route
+	Fault *FaultInjection `yaml:"fault"`
+	// RequestMirrorPolicies shadow requests to other clusters. The responses
+	// of the mirrors are ignored.
+	RequestMirrorPolicies []RequestMirrorPolicy `yaml:"requestMirrorPolicies"`
+	HeaderManipulation    `yaml:",inline"`
+}
+
+// RegexRewrite substitutes Pattern in the path with Substitution, which may
+// reference capture groups as \1.
+type RegexRewrite struct {
+	Pattern      string `yaml:"pattern"`
+	Substitution string `yaml:"substitution"`
+}
+
+// Redirect answers with a redirect to the request URL modified by the set
+// fields. At most one of Path or PrefixRewrite may be set. ResponseCode is
+// one of 301, 302, 303, 307 or 308 and defaults to 301.
+type Redirect struct {
+	HTTPSRedirect bool   `yaml:"httpsRedirect"`
+	Host          string `yaml:"host"`
+	Port          uint32 `yaml:"port"`
+	Path          string `yaml:"path"`
+	PrefixRewrite string `yaml:"prefixRewrite"`
+	ResponseCode  uint32 `yaml:"responseCode"`
+	StripQuery    bool   `yaml:"stripQuery"`
+}
+
+// DirectResponse answers with Status and an optional Body, e.g. for
+// maintenance pages or health endpoints.
+type DirectResponse struct {
+	Status uint32 `yaml:"status"`
+	Body   string `yaml:"body"`
+}
+
+// FaultInjection delays and/or aborts requests. Only requests matching all
+// Headers are faulted when they are set.
+type FaultInjection struct {
+	Delay   *FaultDelay     `yaml:"delay"`
+	Abort   *FaultAbort     `yaml:"abort"`
+	Headers []HeaderMatcher `yaml:"headers"`
+}
+
+// FaultDelay delays Percent of the requests, all of them when unset, by
+// exactly one of FixedDelay or, with FromHeader, the milliseconds given in
+// the x-envoy-fault-delay-request header.
+type FaultDelay struct {
+	FixedDelay time.Duration `yaml:"fixedDelay"`
+	FromHeader bool          `yaml:"fromHeader"`
+	Percent    uint32        `yaml:"percent"`
+}
+
+// FaultAbort answers Percent of the requests, all of them when unset, with
+// exactly one of HTTPStatus or, with FromHeader, the status given in the
+// x-envoy-fault-abort-request header.
+type FaultAbort struct {
+	HTTPStatus uint32 `yaml:"httpStatus"`
+	FromHeader bool   `yaml:"fromHeader"`
+	Percent    uint32 `yaml:"percent"`
+}
+
+// RequestMirrorPolicy mirrors Percent of the requests to Cluster, or all of
+// them when Percent is unset. The percentage can be overridden at runtime
+// through RuntimeKey.
+type RequestMirrorPolicy struct {
+	Cluster    string `yaml:"cluster"`
+	Percent    uint32 `yaml:"percent"`
+	RuntimeKey string `yaml:"runtimeKey"`
+}
+
+// LocalRateLimit is a token bucket holding up to MaxTokens. TokensPerFill
+// tokens are added every FillInterval and default to MaxTokens. Requests are
+// rejected with a 429 when the bucket is empty.
+type LocalRateLimit struct {
+	MaxTokens     uint32        `yaml:"maxTokens"`
+	TokensPerFill uint32        `yaml:"tokensPerFill"`
+	FillInterval  time.Duration `yaml:"fillInterval"`
+}
+
+// RateLimit builds a descriptor from the entries produced by its actions.
+// The request is not rate limited when an action cannot produce its entry.
+type RateLimit struct {
+	Actions []RateLimitAction `yaml:"actions"`
+}
+
+// RateLimitAction produces a descriptor entry from exactly one of a fixed
+// GenericKey value, a RequestHeader, the client RemoteAddress or the
+// DestinationCluster of the route.
+type RateLimitAction struct {
+	GenericKey         string                   `yaml:"genericKey"`
+	RequestHeader      *RequestHeaderDescriptor `yaml:"requestHeader"`
+	RemoteAddress      bool                     `yaml:"remoteAddress"`
+	DestinationCluster bool                     `yaml:"destinationCluster"`
+}
+
+// RequestHeaderDescriptor produces the entry DescriptorKey with the value of
+// the request header HeaderName.
+type RequestHeaderDescriptor struct {
+	HeaderName    string `yaml:"headerName"`
+	DescriptorKey string `yaml:"descriptorKey"`
+}
+
+// HashPolicy hashes on exactly one of Header, Cookie or SourceIP. Terminal
+// skips the remaining policies once this one produced a hash.
+type HashPolicy struct {
+	Header   string            `yaml:"header"`
+	Cookie   *CookieHashPolicy `yaml:"cookie"`
+	SourceIP bool              `yaml:"sourceIP"`
+	Terminal bool              `yaml:"terminal"`
+}
+
+// CookieHashPolicy makes Envoy generate the cookie when TTL is set and the
+// request does not carry it yet.
+type CookieHashPolicy struct {
+	Name string        `yaml:"name"`
+	TTL  time.Duration `yaml:"ttl"`
+	Path string        `yaml:"path"`
+}
+
+// RetryPolicy defaults to retrying once on gateway errors, connect failures
+// and refused streams.
+type RetryPolicy struct {
+	RetryOn       string        `yaml:"retryOn"`
+	NumRetries    uint32        `yaml:"numRetries"`
+	PerTryTimeout time.Duration `yaml:"perTryTimeout"`
+	Backoff       *RetryBackoff `yaml:"backoff"`
+}
+
+type RetryBackoff struct {
+	BaseInterval time.Duration `yaml:"baseInterval"`
+	MaxInterval  time.Duration `yaml:"maxInterval"`
+}
+
+// HeaderMatcher matches a request header on exactly one of Exact, Prefix,
+// Regex or Present. Invert negates the result.
+type HeaderMatcher struct {
+	Name    string `yaml:"name"`
+	Exact   string `yaml:"exact"`
+	Prefix  string `yaml:"prefix"`
+	Regex   string `yaml:"regex"`
+	Present bool   `yaml:"present"`
+	Invert  bool   `yaml:"invert"`
+}
+
+// QueryParameterMatcher matches a query parameter on exactly one of Exact,
+// Prefix, Regex or Present.
+type QueryParameterMatcher struct {
+	Name    string `yaml:"name"`
+	Exact   string `yaml:"exact"`
+	Prefix  string `yaml:"prefix"`
+	Regex   string `yaml:"regex"`
+	Present bool   `yaml:"present"`
+}
+
+type WeightedCluster struct {
+	Name   string `yaml:"name"`
+	Weight uint32 `yaml:"weight"`
 }
 
 type DiscoveryType string
@@ -33,20 +434,205 @@ const (
 	LogicalDNS DiscoveryType = "LogicalDNS"
 	StrictDNS                = "StrictDNS"
 	Static                   = "Static"
+	EDS                      = "EDS"
+)
+
+type LbPolicy string
+
+const (
+	RoundRobin   LbPolicy = "ROUND_ROBIN"
+	LeastRequest LbPolicy = "LEAST_REQUEST"
+	Random       LbPolicy = "RANDOM"
+	RingHash     LbPolicy = "RING_HASH"
+	Maglev       LbPolicy = "MAGLEV"
 )
 
 type Cluster struct {
-	Name          string `yaml:"name"`
-	IsHTTPS       bool   `yaml:"isHTTPS"`
+	Name string `yaml:"name"`
+	// TLS enables TLS to the upstream hosts when set
+	TLS *UpstreamTLS `yaml:"tls"`
+	// Deprecated: IsHTTPS is kept for existing configs and is the same as
+	// an empty TLS block
+	IsHTTPS       bool `yaml:"isHTTPS"`
 	DiscoveryType `yaml:"discoveryType"`
 	Endpoints     []Endpoint `yaml:"endpoints"`
+	// ConnectTimeout defaults to 5s when unset
+	ConnectTimeout time.Duration `yaml:"connectTimeout"`
+	RetryBudget    *RetryBudget  `yaml:"retryBudget"`
+	// LbPolicy defaults to ROUND_ROBIN when unset
+	LbPolicy           LbPolicy              `yaml:"lbPolicy"`
+	LeastRequestConfig *LeastRequestLbConfig `yaml:"leastRequest"`
+	RingHashConfig     *RingHashLbConfig     `yaml:"ringHash"`
+	MaglevConfig       *MaglevLbConfig       `yaml:"maglev"`
+	HealthCheck        *HealthCheck          `yaml:"healthCheck"`
+	CircuitBreakers    *CircuitBreakers      `yaml:"circuitBreakers"`
+	OutlierDetection   *OutlierDetection     `yaml:"outlierDetection"`
+	// Protocol defaults to http2 for clusters with a gRPC health check and
+	// to http1 otherwise. gRPC backends require http2.
+	Protocol     UpstreamProtocol `yaml:"protocol"`
+	HTTP2        *HTTP2Options    `yaml:"http2"`
+	TCPKeepalive *TCPKeepalive    `yaml:"tcpKeepalive"`
+	// ZoneAwareRouting prefers the endpoints in the zone of the Envoy itself.
+	// It requires the Envoy bootstrap to set the node locality and
+	// cluster_manager.local_cluster_name.
+	ZoneAwareRouting *ZoneAwareRouting `yaml:"zoneAwareRouting"`
+}
+
+// ZoneAwareRouting applies to Percent of the requests, 100 when unset, once
+// the cluster has at least MinClusterSize hosts, 6 when unset.
+type ZoneAwareRouting struct {
+	Percent        uint32 `yaml:"percent"`
+	MinClusterSize uint64 `yaml:"minClusterSize"`
+}
+
+// UpstreamProtocol is the HTTP protocol Envoy speaks to the hosts of a
+// cluster.
+type UpstreamProtocol string
+
+const (
+	HTTP1 UpstreamProtocol = "http1"
+	HTTP2 UpstreamProtocol = "http2"
+	// AutoProtocol uses the protocol of the downstream request
+	AutoProtocol UpstreamProtocol = "auto"
+)
+
+// HTTP2Options tunes the HTTP/2 connections of http2 and auto clusters.
+// Unset fields keep Envoy's defaults. KeepaliveInterval sends HTTP/2 PINGs
+// on idle connections, which are closed when a PING is not acknowledged
+// within KeepaliveTimeout.
+type HTTP2Options struct {
+	MaxConcurrentStreams        uint32        `yaml:"maxConcurrentStreams"`
+	InitialStreamWindowSize     uint32        `yaml:"initialStreamWindowSize"`
+	InitialConnectionWindowSize uint32        `yaml:"initialConnectionWindowSize"`
+	KeepaliveInterval           time.Duration `yaml:"keepaliveInterval"`
+	KeepaliveTimeout            time.Duration `yaml:"keepaliveTimeout"`
+}
+
+// TCPKeepalive enables SO_KEEPALIVE on the upstream connections. Unset
+// fields keep the defaults of the operating system.
+type TCPKeepalive struct {
+	Probes   uint32        `yaml:"probes"`
+	Time     time.Duration `yaml:"time"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// CircuitBreakers caps the connections and requests Envoy opens to a
+// cluster. Unset thresholds keep Envoy's default of 1024 (3 for retries).
+type CircuitBreakers struct {
+	MaxConnections     uint32 `yaml:"maxConnections"`
+	MaxPendingRequests uint32 `yaml:"maxPendingRequests"`
+	MaxRequests        uint32 `yaml:"maxRequests"`
+	MaxRetries         uint32 `yaml:"maxRetries"`
+}
+
+// OutlierDetection ejects hosts that keep failing. Unset fields keep Envoy's
+// defaults of 5 consecutive 5xx, a 10s interval, a 30s base ejection time
+// and a 10% max ejection percent.
+type OutlierDetection struct {
+	Consecutive5xx     uint32        `yaml:"consecutive5xx"`
+	Interval           time.Duration `yaml:"interval"`
+	BaseEjectionTime   time.Duration `yaml:"baseEjectionTime"`
+	MaxEjectionPercent uint32        `yaml:"maxEjectionPercent"`
+}
+
+type LeastRequestLbConfig struct {
+	ChoiceCount uint32 `yaml:"choiceCount"`
+}
+
+type RingHashLbConfig struct {
+	MinimumRingSize uint64 `yaml:"minimumRingSize"`
+	MaximumRingSize uint64 `yaml:"maximumRingSize"`
+}
+
+type MaglevLbConfig struct {
+	TableSize uint64 `yaml:"tableSize"`
+}
+
+// RetryBudget limits concurrent retries to a percentage of the active
+// requests. Envoy defaults to 20% and a minimum of 3 concurrent retries.
+type RetryBudget struct {
+	BudgetPercent       float64 `yaml:"budgetPercent"`
+	MinRetryConcurrency uint32  `yaml:"minRetryConcurrency"`
+}
+
+// HealthCheck actively probes the hosts of a cluster with exactly one of
+// HTTP, TCP or GRPC. Unset intervals and thresholds get defaults of a 10s
+// interval, 1s timeout, 2 healthy and 3 unhealthy checks.
+type HealthCheck struct {
+	Interval           time.Duration    `yaml:"interval"`
+	Timeout            time.Duration    `yaml:"timeout"`
+	HealthyThreshold   uint32           `yaml:"healthyThreshold"`
+	UnhealthyThreshold uint32           `yaml:"unhealthyThreshold"`
+	HTTP               *HTTPHealthCheck `yaml:"http"`
+	TCP                *TCPHealthCheck  `yaml:"tcp"`
+	GRPC               *GRPCHealthCheck `yaml:"grpc"`
 }
 
+// HTTPHealthCheck expects a 200 response unless ExpectedStatuses is set.
+type HTTPHealthCheck struct {
+	Path             string        `yaml:"path"`
+	Host             string        `yaml:"host"`
+	ExpectedStatuses []StatusRange `yaml:"expectedStatuses"`
+}
+
+// StatusRange is the half-open range of status codes [Start, End).
+type StatusRange struct {
+	Start int64 `yaml:"start"`
+	End   int64 `yaml:"end"`
+}
+
+// TCPHealthCheck only checks that a connection can be established unless
+// hex encoded Send and Receive payloads are set.
+type TCPHealthCheck struct {
+	Send    string   `yaml:"send"`
+	Receive []string `yaml:"receive"`
+}
+
+// GRPCHealthCheck uses the grpc.health.v1.Health service. The cluster talks
+// HTTP/2 to its hosts when it is configured.
+type GRPCHealthCheck struct {
+	ServiceName string `yaml:"serviceName"`
+	Authority   string `yaml:"authority"`
+}
+
+// UpstreamTLS configures the TLS connection to the hosts of a cluster. The
+// server certificate is only verified when CAFile is set, and CertFile and
+// KeyFile present a client certificate for mTLS.
+type UpstreamTLS struct {
+	SNI                   string   `yaml:"sni"`
+	CAFile                string   `yaml:"caFile"`
+	VerifySubjectAltNames []string `yaml:"verifySubjectAltNames"`
+	CertFile              string   `yaml:"certFile"`
+	KeyFile               string   `yaml:"keyFile"`
+	ALPNProtocols         []string `yaml:"alpnProtocols"`
+}
+
+// Endpoint is a host of a cluster. Endpoints sharing a region, zone,
+// subZone and priority form one locality. Traffic fails over from priority 0
+// to the next priority as hosts become unhealthy, so priorities must be
+// contiguous from 0. Weight defaults to 1.
 type Endpoint struct {
-	Address string `yaml:"address"`
-	Port    uint32 `yaml:"port"`
+	Address      string       `yaml:"address"`
+	Port         uint32       `yaml:"port"`
+	Region       string       `yaml:"region"`
+	Zone         string       `yaml:"zone"`
+	SubZone      string       `yaml:"subZone"`
+	Priority     uint32       `yaml:"priority"`
+	Weight       uint32       `yaml:"weight"`
+	HealthStatus HealthStatus `yaml:"healthStatus"`
 }
 
+// HealthStatus overrides the health of an endpoint. Unset statuses leave it
+// to the active health checks of the cluster.
+type HealthStatus string
+
+const (
+	Healthy   HealthStatus = "healthy"
+	Unhealthy HealthStatus = "unhealthy"
+	Draining  HealthStatus = "draining"
+	Degraded  HealthStatus = "degraded"
+)
+
 type ExtAuthz struct {
 	RouteKey string          `yaml:"routeKey"`
 	Routes   []ExtAuthzRoute `yaml:"routes"`
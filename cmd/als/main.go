@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	server "github.com/weinong/envoy-control-plane/internal/server/als"
+)
+
+var (
+	clusterName string
+	port        uint
+	logFile     string
+	maxSizeMB   int64
+	maxBackups  int
+)
+
+func init() {
+	// The port that this access log server listens on
+	flag.UintVar(&port, "port", 9002, "access log server port")
+
+	flag.StringVar(&clusterName, "clusterName", "cluster1", "cluster name that access logs are tagged with")
+
+	// Access logs are written to stdout unless a file is given
+	flag.StringVar(&logFile, "logFile", "", "file to write access logs to, defaults to stdout")
+	flag.Int64Var(&maxSizeMB, "maxSizeMB", 100, "size in megabytes at which the log file is rotated")
+	flag.IntVar(&maxBackups, "maxBackups", 5, "number of rotated log files to keep")
+}
+
+func main() {
+	flag.Parse()
+
+	var out io.Writer = os.Stdout
+	if logFile != "" {
+		f, err := server.OpenRotatingFile(logFile, maxSizeMB*1024*1024, maxBackups)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer f.Close()
+		out = f
+	}
+
+	srv := server.NewServer(clusterName, out)
+	server.Run(srv, port)
+}
@@ -63,6 +63,21 @@ func main() {
 		watcher.Watch(watchDirectoryFileName, notifyCh)
 	}()
 
+	// Also watch the directories of the certificates served over SDS so
+	// that rotated certificates are pushed to Envoy
+	watchedDirectories := map[string]bool{path.Clean(watchDirectoryFileName): true}
+	watchSecretFiles := func() {
+		for _, f := range proc.SecretFiles() {
+			dir := path.Dir(f)
+			if watchedDirectories[dir] {
+				continue
+			}
+			watchedDirectories[dir] = true
+			go watcher.Watch(dir, notifyCh)
+		}
+	}
+	watchSecretFiles()
+
 	go func() {
 		// Run the xDS server
 		ctx := context.Background()
@@ -78,6 +93,7 @@ func main() {
 			}
 			log.Infof("process file %v", msg)
 			proc.ProcessFile(msg)
+			watchSecretFiles()
 		}
 	}
 }
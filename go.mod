@@ -3,6 +3,7 @@ module github.com/weinong/envoy-control-plane
 go 1.13
 
 require (
+	github.com/cncf/udpa/go v0.0.0-20200629203442-efcf912fb354
 	github.com/envoyproxy/go-control-plane v0.9.7
 	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gogo/googleapis v1.4.0
@@ -0,0 +1,70 @@
+package processor
+
+import (
+	"sort"
+
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/golang/protobuf/proto"
+)
+
+type changeOperation string
+
+const (
+	added   changeOperation = "added"
+	changed changeOperation = "changed"
+	removed changeOperation = "removed"
+)
+
+var resourceTypeNames = map[types.ResponseType]string{
+	types.Endpoint: "endpoint",
+	types.Cluster:  "cluster",
+	types.Route:    "route",
+	types.Listener: "listener",
+	types.Secret:   "secret",
+	types.Runtime:  "runtime",
+}
+
+// change describes a single resource that differs between two snapshots.
+type change struct {
+	Operation changeOperation
+	Type      string
+	Name      string
+}
+
+// diffSnapshots returns the resources that were added, changed or removed
+// when moving from the previous snapshot to the next one.
+func diffSnapshots(previous, next cache.Snapshot) []change {
+	var changes []change
+
+	for typ := types.ResponseType(0); typ < types.UnknownType; typ++ {
+		prevItems := previous.Resources[typ].Items
+		nextItems := next.Resources[typ].Items
+
+		for _, name := range sortedNames(nextItems) {
+			prev, ok := prevItems[name]
+			if !ok {
+				changes = append(changes, change{Operation: added, Type: resourceTypeNames[typ], Name: name})
+			} else if !proto.Equal(prev, nextItems[name]) {
+				changes = append(changes, change{Operation: changed, Type: resourceTypeNames[typ], Name: name})
+			}
+		}
+
+		for _, name := range sortedNames(prevItems) {
+			if _, ok := nextItems[name]; !ok {
+				changes = append(changes, change{Operation: removed, Type: resourceTypeNames[typ], Name: name})
+			}
+		}
+	}
+
+	return changes
+}
+
+func sortedNames(items map[string]types.Resource) []string {
+	names := make([]string, 0, len(items))
+	for name := range items {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
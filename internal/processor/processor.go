@@ -10,7 +10,6 @@ import (
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 
 	"github.com/envoyproxy/go-control-plane/pkg/cache/v3"
-	"github.com/weinong/envoy-control-plane/internal/resources"
 	"github.com/weinong/envoy-control-plane/internal/utils"
 	"github.com/weinong/envoy-control-plane/internal/watcher"
 	"github.com/weinong/envoy-control-plane/internal/xdscache"
@@ -24,7 +23,10 @@ type Processor struct {
 	// snapshotVersion holds the current version of the snapshot.
 	snapshotVersion int64
 
-	xdsCache xdscache.XDSCache
+	// configFile is the last config file a snapshot was built from, and
+	// secretFiles the certificate, key and CA files that snapshot read.
+	configFile  string
+	secretFiles map[string]bool
 }
 
 func NewProcessor(name string, cache cache.SnapshotCache, nodeID string) *Processor {
@@ -33,12 +35,6 @@ func NewProcessor(name string, cache cache.SnapshotCache, nodeID string) *Proces
 		cache:           cache,
 		nodeID:          nodeID,
 		snapshotVersion: rand.Int63n(1000),
-		xdsCache: xdscache.XDSCache{
-			Listeners: make(map[string]resources.Listener),
-			Clusters:  make(map[string]resources.Cluster),
-			Routes:    make(map[string]resources.Route),
-			Endpoints: make(map[string]resources.Endpoint),
-		},
 	}
 }
 
@@ -56,9 +52,26 @@ func (p *Processor) newSnapshotVersion() string {
 	return strconv.FormatInt(p.snapshotVersion, 10)
 }
 
+// SecretFiles returns the certificate, key and CA files served over SDS
+// so that they can be watched for changes.
+func (p *Processor) SecretFiles() []string {
+	var files []string
+	for f := range p.secretFiles {
+		files = append(files, f)
+	}
+	return files
+}
+
 // ProcessFile takes a file and generates an xDS snapshot
 func (p *Processor) ProcessFile(file watcher.NotifyMessage) {
 
+	// A change to a secret file is picked up by rebuilding the snapshot from
+	// the config file that references it
+	if p.secretFiles[file.FilePath] {
+		log.Printf("secret file %s changed, reprocessing %s", file.FilePath, p.configFile)
+		file.FilePath = p.configFile
+	}
+
 	// Parse file into object
 	envoyConfig, err := utils.ParseEnvoyConfig(file.FilePath)
 	if err != nil {
@@ -71,48 +84,59 @@ func (p *Processor) ProcessFile(file watcher.NotifyMessage) {
 		return
 	}
 
-	// hack: pass route key to xds cache
-	p.xdsCache.RouteKey = envoyConfig.RouteKey
+	// Rebuild the desired state from scratch so that resources removed
+	// from the file are dropped from the next snapshot
+	xdsCache := xdscache.NewXDSCache()
 
 	// Parse Listeners
 	for _, l := range envoyConfig.Listeners {
-		var lRoutes []string
-		for _, lr := range l.Routes {
-			lRoutes = append(lRoutes, lr.Name)
-		}
-
-		p.xdsCache.AddListener(l.Name, lRoutes, l.Address, l.Port, l.CertFile, l.KeyFile)
-
-		for _, r := range l.Routes {
-			p.xdsCache.AddRoute(r.Name, r.Prefix, r.Header, r.HostRewrite)
+		if err := xdsCache.AddListener(l); err != nil {
+			log.Printf("invalid listener %s: %s", l.Name, err)
+			return
 		}
 	}
 
 	// Parse Clusters
 	for _, c := range envoyConfig.Clusters {
-		p.xdsCache.AddCluster(c)
+		if err := xdsCache.AddCluster(c); err != nil {
+			log.Printf("invalid cluster %s: %s", c.Name, err)
+			return
+		}
 	}
 
 	// Create the snapshot that we'll serve to Envoy
+	version := p.newSnapshotVersion()
 	snapshot := cache.NewSnapshot(
-		p.newSnapshotVersion(),        // version
-		[]types.Resource{},            // endpoints
-		p.xdsCache.ClusterContents(),  // clusters
-		p.xdsCache.RouteContents(),    // routes
-		p.xdsCache.ListenerContents(), // listeners
-		[]types.Resource{},            // runtimes
-		[]types.Resource{},            // secrets
+		version,                     // version
+		xdsCache.EndpointContents(), // endpoints
+		xdsCache.ClusterContents(),  // clusters
+		xdsCache.RouteContents(),    // routes
+		xdsCache.ListenerContents(), // listeners
+		[]types.Resource{},          // runtimes
+		xdsCache.SecretContents(),   // secrets
 	)
 
+	// The snapshot is never logged as a whole since its secrets hold the
+	// private keys
 	if err := snapshot.Consistent(); err != nil {
-		log.Printf("snapshot inconsistency: %+v\n\n\n%+v", snapshot, err)
+		log.Printf("snapshot %s inconsistency: %s", version, err)
 		return
 	}
-	log.Printf("will serve snapshot %+v", snapshot)
+	log.Printf("will serve snapshot %s", version)
+
+	// Log what changed compared to the snapshot currently being served.
+	// There is no previous snapshot on the first run, so everything is added.
+	previous, _ := p.cache.GetSnapshot(p.nodeID)
+	for _, c := range diffSnapshots(previous, snapshot) {
+		log.Printf("%s %s %q", c.Operation, c.Type, c.Name)
+	}
 
 	// Add the snapshot to the cache
 	if err := p.cache.SetSnapshot(p.nodeID, snapshot); err != nil {
-		log.Printf("snapshot error %q for %+v", err, snapshot)
+		log.Printf("snapshot error %q for snapshot %s", err, version)
 		os.Exit(1)
 	}
+
+	p.configFile = file.FilePath
+	p.secretFiles = xdsCache.SecretFiles
 }
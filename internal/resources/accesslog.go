@@ -0,0 +1,172 @@
+package resources
+
+import (
+	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_file_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
+	envoy_grpc_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/grpc/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+	"github.com/weinong/envoy-control-plane/internal/utils"
+)
+
+const (
+	tcpGRPCAccessLog = "envoy.access_loggers.tcp_grpc"
+
+	// Envoy requires a runtime key for the values of comparison filters even
+	// though they are not meant to be overridden
+	statusCodeFilterRuntimeKey = "access_log.status_code_filter"
+	durationFilterRuntimeKey   = "access_log.duration_filter"
+)
+
+var comparisonOps = map[string]accesslog.ComparisonFilter_Op{
+	"ge": accesslog.ComparisonFilter_GE,
+	"eq": accesslog.ComparisonFilter_EQ,
+	"le": accesslog.ComparisonFilter_LE,
+}
+
+// makeAccessLogs builds the access logs of a listener, defaulting to a file
+// access log writing to /dev/stdout.
+func makeAccessLogs(resource Listener) []*accesslog.AccessLog {
+	if len(resource.AccessLogs) == 0 {
+		return []*accesslog.AccessLog{
+			{
+				Name: wellknown.FileAccessLog,
+				ConfigType: &accesslog.AccessLog_TypedConfig{
+					TypedConfig: utils.MustMarshalAny(&envoy_file_v3.FileAccessLog{
+						Path: "/dev/stdout",
+					}),
+				},
+			},
+		}
+	}
+
+	var logs []*accesslog.AccessLog
+	for _, l := range resource.AccessLogs {
+		al := &accesslog.AccessLog{}
+		if l.File != nil {
+			al.Name = wellknown.FileAccessLog
+			al.ConfigType = &accesslog.AccessLog_TypedConfig{
+				TypedConfig: utils.MustMarshalAny(makeFileAccessLog(l.File)),
+			}
+		} else {
+			common := &envoy_grpc_v3.CommonGrpcAccessLogConfig{
+				LogName: l.GRPC.LogName,
+				GrpcService: &core.GrpcService{
+					TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+						EnvoyGrpc: &core.GrpcService_EnvoyGrpc{ClusterName: l.GRPC.Cluster},
+					},
+				},
+				TransportApiVersion: core.ApiVersion_V3,
+			}
+			if common.LogName == "" {
+				common.LogName = resource.Name
+			}
+			if resource.Protocol == v1alpha1.HTTP {
+				al.Name = wellknown.HTTPGRPCAccessLog
+				al.ConfigType = &accesslog.AccessLog_TypedConfig{
+					TypedConfig: utils.MustMarshalAny(&envoy_grpc_v3.HttpGrpcAccessLogConfig{CommonConfig: common}),
+				}
+			} else {
+				al.Name = tcpGRPCAccessLog
+				al.ConfigType = &accesslog.AccessLog_TypedConfig{
+					TypedConfig: utils.MustMarshalAny(&envoy_grpc_v3.TcpGrpcAccessLogConfig{CommonConfig: common}),
+				}
+			}
+		}
+		if l.Filter != nil {
+			al.Filter = makeAccessLogFilter(l.Filter)
+		}
+		logs = append(logs, al)
+	}
+
+	return logs
+}
+
+func makeFileAccessLog(file *v1alpha1.FileAccessLog) *envoy_file_v3.FileAccessLog {
+	fal := &envoy_file_v3.FileAccessLog{
+		Path: file.Path,
+	}
+
+	switch {
+	case file.TextFormat != "":
+		fal.AccessLogFormat = &envoy_file_v3.FileAccessLog_LogFormat{
+			LogFormat: &core.SubstitutionFormatString{
+				Format: &core.SubstitutionFormatString_TextFormat{
+					TextFormat: file.TextFormat,
+				},
+			},
+		}
+	case len(file.JSONFormat) > 0:
+		fields := make(map[string]*structpb.Value)
+		for k, v := range file.JSONFormat {
+			fields[k] = &structpb.Value{Kind: &structpb.Value_StringValue{StringValue: v}}
+		}
+		fal.AccessLogFormat = &envoy_file_v3.FileAccessLog_LogFormat{
+			LogFormat: &core.SubstitutionFormatString{
+				Format: &core.SubstitutionFormatString_JsonFormat{
+					JsonFormat: &structpb.Struct{Fields: fields},
+				},
+			},
+		}
+	}
+
+	return fal
+}
+
+// makeAccessLogFilter combines the conditions of a filter with an and filter
+// when more than one of them is set.
+func makeAccessLogFilter(filter *v1alpha1.AccessLogFilter) *accesslog.AccessLogFilter {
+	var filters []*accesslog.AccessLogFilter
+
+	if sc := filter.StatusCode; sc != nil {
+		filters = append(filters, &accesslog.AccessLogFilter{
+			FilterSpecifier: &accesslog.AccessLogFilter_StatusCodeFilter{
+				StatusCodeFilter: &accesslog.StatusCodeFilter{
+					Comparison: makeComparisonFilter(sc.Op, sc.Value, statusCodeFilterRuntimeKey),
+				},
+			},
+		})
+	}
+	if d := filter.Duration; d != nil {
+		filters = append(filters, &accesslog.AccessLogFilter{
+			FilterSpecifier: &accesslog.AccessLogFilter_DurationFilter{
+				DurationFilter: &accesslog.DurationFilter{
+					Comparison: makeComparisonFilter(d.Op, uint32(d.Value.Milliseconds()), durationFilterRuntimeKey),
+				},
+			},
+		})
+	}
+	if rs := filter.RuntimeSampling; rs != nil {
+		filters = append(filters, &accesslog.AccessLogFilter{
+			FilterSpecifier: &accesslog.AccessLogFilter_RuntimeFilter{
+				RuntimeFilter: &accesslog.RuntimeFilter{
+					RuntimeKey:     rs.RuntimeKey,
+					PercentSampled: makePercent(rs.Percent),
+				},
+			},
+		})
+	}
+
+	if len(filters) == 1 {
+		return filters[0]
+	}
+	return &accesslog.AccessLogFilter{
+		FilterSpecifier: &accesslog.AccessLogFilter_AndFilter{
+			AndFilter: &accesslog.AndFilter{
+				Filters: filters,
+			},
+		},
+	}
+}
+
+func makeComparisonFilter(op string, value uint32, runtimeKey string) *accesslog.ComparisonFilter {
+	return &accesslog.ComparisonFilter{
+		Op: comparisonOps[op],
+		Value: &core.RuntimeUInt32{
+			DefaultValue: value,
+			RuntimeKey:   runtimeKey,
+		},
+	}
+}
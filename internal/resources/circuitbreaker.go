@@ -0,0 +1,53 @@
+package resources
+
+import (
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+// makeCircuitBreakers builds the default priority thresholds. Either
+// argument may be nil.
+func makeCircuitBreakers(cb *v1alpha1.CircuitBreakers, budget *v1alpha1.RetryBudget) *cluster.CircuitBreakers {
+	thresholds := &cluster.CircuitBreakers_Thresholds{
+		Priority: core.RoutingPriority_DEFAULT,
+	}
+
+	if cb != nil {
+		thresholds.MaxConnections = makeUInt32Value(cb.MaxConnections)
+		thresholds.MaxPendingRequests = makeUInt32Value(cb.MaxPendingRequests)
+		thresholds.MaxRequests = makeUInt32Value(cb.MaxRequests)
+		thresholds.MaxRetries = makeUInt32Value(cb.MaxRetries)
+	}
+	if budget != nil {
+		thresholds.RetryBudget = makeRetryBudget(budget)
+	}
+
+	return &cluster.CircuitBreakers{
+		Thresholds: []*cluster.CircuitBreakers_Thresholds{thresholds},
+	}
+}
+
+func makeOutlierDetection(od *v1alpha1.OutlierDetection) *cluster.OutlierDetection {
+	o := &cluster.OutlierDetection{
+		Consecutive_5Xx:    makeUInt32Value(od.Consecutive5xx),
+		MaxEjectionPercent: makeUInt32Value(od.MaxEjectionPercent),
+	}
+	if od.Interval != 0 {
+		o.Interval = ptypes.DurationProto(od.Interval)
+	}
+	if od.BaseEjectionTime != 0 {
+		o.BaseEjectionTime = ptypes.DurationProto(od.BaseEjectionTime)
+	}
+	return o
+}
+
+// makeUInt32Value returns nil for zero so that Envoy applies its default.
+func makeUInt32Value(v uint32) *wrappers.UInt32Value {
+	if v == 0 {
+		return nil
+	}
+	return &wrappers.UInt32Value{Value: v}
+}
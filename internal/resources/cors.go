@@ -0,0 +1,28 @@
+package resources
+
+import (
+	"strconv"
+	"strings"
+
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+func makeCorsPolicy(cors *v1alpha1.CorsPolicy) *route.CorsPolicy {
+	policy := &route.CorsPolicy{
+		AllowMethods:  strings.Join(cors.AllowMethods, ","),
+		AllowHeaders:  strings.Join(cors.AllowHeaders, ","),
+		ExposeHeaders: strings.Join(cors.ExposeHeaders, ","),
+	}
+	for _, o := range cors.AllowOrigins {
+		policy.AllowOriginStringMatch = append(policy.AllowOriginStringMatch, makeStringMatcher(o.Exact, "", o.Regex))
+	}
+	if cors.MaxAge != 0 {
+		policy.MaxAge = strconv.FormatInt(int64(cors.MaxAge.Seconds()), 10)
+	}
+	if cors.AllowCredentials {
+		policy.AllowCredentials = &wrappers.BoolValue{Value: true}
+	}
+	return policy
+}
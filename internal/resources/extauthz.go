@@ -0,0 +1,97 @@
+package resources
+
+import (
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	extauth "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+	"github.com/weinong/envoy-control-plane/internal/utils"
+)
+
+const defaultExtAuthzTimeout = 200 * time.Millisecond
+
+// extAuthzEnabled tells whether the ext_authz filter is installed. A
+// configured filter is enabled unless it is explicitly turned off.
+func extAuthzEnabled(cfg *v1alpha1.ExtAuthzFilter) bool {
+	return cfg != nil && (cfg.Enabled == nil || *cfg.Enabled)
+}
+
+func makeExtAuthzFilter(cfg *v1alpha1.ExtAuthzFilter) *hcm.HttpFilter {
+	timeout := cfg.Timeout
+	if timeout == 0 {
+		timeout = defaultExtAuthzTimeout
+	}
+
+	extAuthz := &extauth.ExtAuthz{
+		TransportApiVersion: core.ApiVersion_V3,
+		FailureModeAllow:    cfg.FailureModeAllow,
+		ClearRouteCache:     cfg.ClearRouteCache,
+	}
+	if body := cfg.IncludeBody; body != nil {
+		extAuthz.WithRequestBody = &extauth.BufferSettings{
+			MaxRequestBytes:     body.MaxBytes,
+			AllowPartialMessage: body.AllowPartialMessage,
+		}
+	}
+
+	if svc := cfg.HTTPService; svc != nil {
+		http := &extauth.HttpService{
+			ServerUri: &core.HttpUri{
+				Uri: "http://" + cfg.Cluster,
+				HttpUpstreamType: &core.HttpUri_Cluster{
+					Cluster: cfg.Cluster,
+				},
+				Timeout: ptypes.DurationProto(timeout),
+			},
+			PathPrefix: svc.PathPrefix,
+		}
+		if len(svc.AllowedHeaders) > 0 {
+			allowed := &matcher.ListStringMatcher{}
+			for _, h := range svc.AllowedHeaders {
+				allowed.Patterns = append(allowed.Patterns, &matcher.StringMatcher{
+					MatchPattern: &matcher.StringMatcher_Exact{Exact: h},
+					IgnoreCase:   true,
+				})
+			}
+			http.AuthorizationRequest = &extauth.AuthorizationRequest{
+				AllowedHeaders: allowed,
+			}
+		}
+		extAuthz.Services = &extauth.ExtAuthz_HttpService{
+			HttpService: http,
+		}
+	} else {
+		extAuthz.Services = &extauth.ExtAuthz_GrpcService{
+			GrpcService: &core.GrpcService{
+				TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &core.GrpcService_EnvoyGrpc{ClusterName: cfg.Cluster},
+				},
+				Timeout: ptypes.DurationProto(timeout),
+			},
+		}
+	}
+
+	return &hcm.HttpFilter{
+		Name: wellknown.HTTPExternalAuthorization,
+		ConfigType: &hcm.HttpFilter_TypedConfig{
+			TypedConfig: utils.MustMarshalAny(extAuthz),
+		},
+	}
+}
+
+// makeExtAuthzDisabled is the per route config turning off the ext_authz
+// filter of the listener.
+func makeExtAuthzDisabled() *any.Any {
+	return utils.MustMarshalAny(&extauth.ExtAuthzPerRoute{
+		Override: &extauth.ExtAuthzPerRoute_Disabled{
+			Disabled: true,
+		},
+	})
+}
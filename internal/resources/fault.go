@@ -0,0 +1,93 @@
+package resources
+
+import (
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_fault_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/common/fault/v3"
+	fault "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+	"github.com/weinong/envoy-control-plane/internal/utils"
+)
+
+// makeFaultFilter installs the fault filter without faults of its own, so
+// that only the routes with a per route config are faulted.
+func makeFaultFilter() *hcm.HttpFilter {
+	return &hcm.HttpFilter{
+		Name: wellknown.Fault,
+		ConfigType: &hcm.HttpFilter_TypedConfig{
+			TypedConfig: utils.MustMarshalAny(&fault.HTTPFault{}),
+		},
+	}
+}
+
+func makeFaultPerRoute(f *v1alpha1.FaultInjection) *any.Any {
+	httpFault := &fault.HTTPFault{}
+
+	if d := f.Delay; d != nil {
+		httpFault.Delay = &envoy_fault_v3.FaultDelay{
+			Percentage: makePercent(percentOrAll(d.Percent)),
+		}
+		if d.FromHeader {
+			httpFault.Delay.FaultDelaySecifier = &envoy_fault_v3.FaultDelay_HeaderDelay_{
+				HeaderDelay: &envoy_fault_v3.FaultDelay_HeaderDelay{},
+			}
+		} else {
+			httpFault.Delay.FaultDelaySecifier = &envoy_fault_v3.FaultDelay_FixedDelay{
+				FixedDelay: ptypes.DurationProto(d.FixedDelay),
+			}
+		}
+	}
+	if a := f.Abort; a != nil {
+		httpFault.Abort = &fault.FaultAbort{
+			Percentage: makePercent(percentOrAll(a.Percent)),
+		}
+		if a.FromHeader {
+			httpFault.Abort.ErrorType = &fault.FaultAbort_HeaderAbort_{
+				HeaderAbort: &fault.FaultAbort_HeaderAbort{},
+			}
+		} else {
+			httpFault.Abort.ErrorType = &fault.FaultAbort_HttpStatus{
+				HttpStatus: a.HTTPStatus,
+			}
+		}
+	}
+	for _, h := range f.Headers {
+		httpFault.Headers = append(httpFault.Headers, makeHeaderMatcher(h))
+	}
+
+	return utils.MustMarshalAny(httpFault)
+}
+
+func makeRequestMirrorPolicy(m v1alpha1.RequestMirrorPolicy) *route.RouteAction_RequestMirrorPolicy {
+	policy := &route.RouteAction_RequestMirrorPolicy{
+		Cluster: m.Cluster,
+	}
+	if m.Percent != 0 || m.RuntimeKey != "" {
+		policy.RuntimeFraction = &core.RuntimeFractionalPercent{
+			DefaultValue: makePercent(percentOrAll(m.Percent)),
+			RuntimeKey:   m.RuntimeKey,
+		}
+	}
+	return policy
+}
+
+// percentOrAll defaults an unset percent to all of the requests.
+func percentOrAll(percent uint32) uint32 {
+	if percent == 0 {
+		return 100
+	}
+	return percent
+}
+
+func makePercent(percent uint32) *envoy_type_v3.FractionalPercent {
+	return &envoy_type_v3.FractionalPercent{
+		Numerator:   percent,
+		Denominator: envoy_type_v3.FractionalPercent_HUNDRED,
+	}
+}
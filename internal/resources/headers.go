@@ -0,0 +1,24 @@
+package resources
+
+import (
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+func makeHeaderValueOptions(headers []v1alpha1.HeaderValue) []*core.HeaderValueOption {
+	var options []*core.HeaderValueOption
+
+	for _, h := range headers {
+		options = append(options, &core.HeaderValueOption{
+			Header: &core.HeaderValue{
+				Key:   h.Name,
+				Value: h.Value,
+			},
+			// envoy appends unless told otherwise, so always set it
+			Append: &wrappers.BoolValue{Value: h.Append},
+		})
+	}
+
+	return options
+}
@@ -0,0 +1,83 @@
+package resources
+
+import (
+	"time"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultHealthCheckTimeout  = 1 * time.Second
+	defaultHealthyThreshold    = 2
+	defaultUnhealthyThreshold  = 3
+)
+
+func makeHealthCheck(hc *v1alpha1.HealthCheck) *core.HealthCheck {
+	interval := hc.Interval
+	if interval == 0 {
+		interval = defaultHealthCheckInterval
+	}
+	timeout := hc.Timeout
+	if timeout == 0 {
+		timeout = defaultHealthCheckTimeout
+	}
+	healthyThreshold := hc.HealthyThreshold
+	if healthyThreshold == 0 {
+		healthyThreshold = defaultHealthyThreshold
+	}
+	unhealthyThreshold := hc.UnhealthyThreshold
+	if unhealthyThreshold == 0 {
+		unhealthyThreshold = defaultUnhealthyThreshold
+	}
+
+	h := &core.HealthCheck{
+		Interval:           ptypes.DurationProto(interval),
+		Timeout:            ptypes.DurationProto(timeout),
+		HealthyThreshold:   &wrappers.UInt32Value{Value: healthyThreshold},
+		UnhealthyThreshold: &wrappers.UInt32Value{Value: unhealthyThreshold},
+	}
+
+	switch {
+	case hc.HTTP != nil:
+		check := &core.HealthCheck_HttpHealthCheck{
+			Path: hc.HTTP.Path,
+			Host: hc.HTTP.Host,
+		}
+		for _, r := range hc.HTTP.ExpectedStatuses {
+			check.ExpectedStatuses = append(check.ExpectedStatuses, &envoy_type_v3.Int64Range{
+				Start: r.Start,
+				End:   r.End,
+			})
+		}
+		h.HealthChecker = &core.HealthCheck_HttpHealthCheck_{HttpHealthCheck: check}
+	case hc.TCP != nil:
+		check := &core.HealthCheck_TcpHealthCheck{}
+		if hc.TCP.Send != "" {
+			check.Send = makeHealthCheckPayload(hc.TCP.Send)
+		}
+		for _, r := range hc.TCP.Receive {
+			check.Receive = append(check.Receive, makeHealthCheckPayload(r))
+		}
+		h.HealthChecker = &core.HealthCheck_TcpHealthCheck_{TcpHealthCheck: check}
+	default:
+		h.HealthChecker = &core.HealthCheck_GrpcHealthCheck_{
+			GrpcHealthCheck: &core.HealthCheck_GrpcHealthCheck{
+				ServiceName: hc.GRPC.ServiceName,
+				Authority:   hc.GRPC.Authority,
+			},
+		}
+	}
+
+	return h
+}
+
+func makeHealthCheckPayload(hex string) *core.HealthCheck_Payload {
+	return &core.HealthCheck_Payload{
+		Payload: &core.HealthCheck_Payload_Text{Text: hex},
+	}
+}
@@ -0,0 +1,78 @@
+package resources
+
+import (
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+// setLbPolicy sets the load balancing policy of c and its optional
+// policy specific configuration.
+func setLbPolicy(c *cluster.Cluster, resource Cluster) {
+	switch resource.LbPolicy {
+	case v1alpha1.LeastRequest:
+		c.LbPolicy = cluster.Cluster_LEAST_REQUEST
+		if resource.LeastRequest != nil && resource.LeastRequest.ChoiceCount != 0 {
+			c.LbConfig = &cluster.Cluster_LeastRequestLbConfig_{
+				LeastRequestLbConfig: &cluster.Cluster_LeastRequestLbConfig{
+					ChoiceCount: &wrappers.UInt32Value{Value: resource.LeastRequest.ChoiceCount},
+				},
+			}
+		}
+	case v1alpha1.Random:
+		c.LbPolicy = cluster.Cluster_RANDOM
+	case v1alpha1.RingHash:
+		c.LbPolicy = cluster.Cluster_RING_HASH
+		if resource.RingHash != nil {
+			config := &cluster.Cluster_RingHashLbConfig{}
+			if resource.RingHash.MinimumRingSize != 0 {
+				config.MinimumRingSize = &wrappers.UInt64Value{Value: resource.RingHash.MinimumRingSize}
+			}
+			if resource.RingHash.MaximumRingSize != 0 {
+				config.MaximumRingSize = &wrappers.UInt64Value{Value: resource.RingHash.MaximumRingSize}
+			}
+			c.LbConfig = &cluster.Cluster_RingHashLbConfig_{RingHashLbConfig: config}
+		}
+	case v1alpha1.Maglev:
+		c.LbPolicy = cluster.Cluster_MAGLEV
+		if resource.Maglev != nil && resource.Maglev.TableSize != 0 {
+			c.LbConfig = &cluster.Cluster_MaglevLbConfig_{
+				MaglevLbConfig: &cluster.Cluster_MaglevLbConfig{
+					TableSize: &wrappers.UInt64Value{Value: resource.Maglev.TableSize},
+				},
+			}
+		}
+	default:
+		c.LbPolicy = cluster.Cluster_ROUND_ROBIN
+	}
+}
+
+func makeHashPolicy(h v1alpha1.HashPolicy) *route.RouteAction_HashPolicy {
+	p := &route.RouteAction_HashPolicy{
+		Terminal: h.Terminal,
+	}
+
+	switch {
+	case h.Header != "":
+		p.PolicySpecifier = &route.RouteAction_HashPolicy_Header_{
+			Header: &route.RouteAction_HashPolicy_Header{HeaderName: h.Header},
+		}
+	case h.Cookie != nil:
+		cookie := &route.RouteAction_HashPolicy_Cookie{
+			Name: h.Cookie.Name,
+			Path: h.Cookie.Path,
+		}
+		if h.Cookie.TTL != 0 {
+			cookie.Ttl = ptypes.DurationProto(h.Cookie.TTL)
+		}
+		p.PolicySpecifier = &route.RouteAction_HashPolicy_Cookie_{Cookie: cookie}
+	default:
+		p.PolicySpecifier = &route.RouteAction_HashPolicy_ConnectionProperties_{
+			ConnectionProperties: &route.RouteAction_HashPolicy_ConnectionProperties{SourceIp: h.SourceIP},
+		}
+	}
+
+	return p
+}
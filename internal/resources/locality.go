@@ -0,0 +1,49 @@
+package resources
+
+import (
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+// localityKey identifies the LocalityLbEndpoints an endpoint belongs to.
+type localityKey struct {
+	region, zone, subZone string
+	priority              uint32
+}
+
+var healthStatuses = map[v1alpha1.HealthStatus]core.HealthStatus{
+	"":                 core.HealthStatus_UNKNOWN,
+	v1alpha1.Healthy:   core.HealthStatus_HEALTHY,
+	v1alpha1.Unhealthy: core.HealthStatus_UNHEALTHY,
+	v1alpha1.Draining:  core.HealthStatus_DRAINING,
+	v1alpha1.Degraded:  core.HealthStatus_DEGRADED,
+}
+
+func makeLocality(e Endpoint) *core.Locality {
+	if e.Region == "" && e.Zone == "" && e.SubZone == "" {
+		return nil
+	}
+	return &core.Locality{
+		Region:  e.Region,
+		Zone:    e.Zone,
+		SubZone: e.SubZone,
+	}
+}
+
+func makeZoneAwareLbConfig(zoneAware *v1alpha1.ZoneAwareRouting) *cluster.Cluster_CommonLbConfig {
+	config := &cluster.Cluster_CommonLbConfig_ZoneAwareLbConfig{}
+	if zoneAware.Percent != 0 {
+		config.RoutingEnabled = &envoy_type_v3.Percent{Value: float64(zoneAware.Percent)}
+	}
+	if zoneAware.MinClusterSize != 0 {
+		config.MinClusterSize = &wrappers.UInt64Value{Value: zoneAware.MinClusterSize}
+	}
+	return &cluster.Cluster_CommonLbConfig{
+		LocalityConfigSpecifier: &cluster.Cluster_CommonLbConfig_ZoneAwareLbConfig_{
+			ZoneAwareLbConfig: config,
+		},
+	}
+}
@@ -0,0 +1,84 @@
+package resources
+
+import (
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+func makeRouteMatch(r Route) *route.RouteMatch {
+	m := &route.RouteMatch{}
+
+	switch {
+	case r.Path != "":
+		m.PathSpecifier = &route.RouteMatch_Path{Path: r.Path}
+	case r.SafeRegex != "":
+		m.PathSpecifier = &route.RouteMatch_SafeRegex{SafeRegex: makeRegexMatcher(r.SafeRegex)}
+	default:
+		m.PathSpecifier = &route.RouteMatch_Prefix{Prefix: r.Prefix}
+	}
+
+	for _, h := range r.Headers {
+		m.Headers = append(m.Headers, makeHeaderMatcher(h))
+	}
+	for _, q := range r.QueryParameters {
+		m.QueryParameters = append(m.QueryParameters, makeQueryParameterMatcher(q))
+	}
+
+	return m
+}
+
+func makeHeaderMatcher(h v1alpha1.HeaderMatcher) *route.HeaderMatcher {
+	m := &route.HeaderMatcher{
+		Name:        h.Name,
+		InvertMatch: h.Invert,
+	}
+
+	switch {
+	case h.Exact != "":
+		m.HeaderMatchSpecifier = &route.HeaderMatcher_ExactMatch{ExactMatch: h.Exact}
+	case h.Prefix != "":
+		m.HeaderMatchSpecifier = &route.HeaderMatcher_PrefixMatch{PrefixMatch: h.Prefix}
+	case h.Regex != "":
+		m.HeaderMatchSpecifier = &route.HeaderMatcher_SafeRegexMatch{SafeRegexMatch: makeRegexMatcher(h.Regex)}
+	default:
+		m.HeaderMatchSpecifier = &route.HeaderMatcher_PresentMatch{PresentMatch: h.Present}
+	}
+
+	return m
+}
+
+func makeQueryParameterMatcher(q v1alpha1.QueryParameterMatcher) *route.QueryParameterMatcher {
+	m := &route.QueryParameterMatcher{
+		Name: q.Name,
+	}
+
+	if q.Present {
+		m.QueryParameterMatchSpecifier = &route.QueryParameterMatcher_PresentMatch{PresentMatch: true}
+		return m
+	}
+
+	m.QueryParameterMatchSpecifier = &route.QueryParameterMatcher_StringMatch{
+		StringMatch: makeStringMatcher(q.Exact, q.Prefix, q.Regex),
+	}
+	return m
+}
+
+// makeStringMatcher matches on the first of exact, prefix or regex that is set.
+func makeStringMatcher(exact, prefix, regex string) *matcher.StringMatcher {
+	switch {
+	case exact != "":
+		return &matcher.StringMatcher{MatchPattern: &matcher.StringMatcher_Exact{Exact: exact}}
+	case prefix != "":
+		return &matcher.StringMatcher{MatchPattern: &matcher.StringMatcher_Prefix{Prefix: prefix}}
+	default:
+		return &matcher.StringMatcher{MatchPattern: &matcher.StringMatcher_SafeRegex{SafeRegex: makeRegexMatcher(regex)}}
+	}
+}
+
+func makeRegexMatcher(regex string) *matcher.RegexMatcher {
+	return &matcher.RegexMatcher{
+		EngineType: &matcher.RegexMatcher_GoogleRe2{GoogleRe2: &matcher.RegexMatcher_GoogleRE2{}},
+		Regex:      regex,
+	}
+}
@@ -0,0 +1,65 @@
+package resources
+
+import (
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+// setProtocolOptions sets the HTTP protocol of c and the options of its
+// upstream connections. http1 is Envoy's default and needs no options.
+func setProtocolOptions(c *cluster.Cluster, resource Cluster) {
+	switch resource.Protocol {
+	case v1alpha1.HTTP2:
+		c.Http2ProtocolOptions = makeHTTP2ProtocolOptions(resource.HTTP2)
+	case v1alpha1.AutoProtocol:
+		c.ProtocolSelection = cluster.Cluster_USE_DOWNSTREAM_PROTOCOL
+		c.HttpProtocolOptions = &core.Http1ProtocolOptions{}
+		c.Http2ProtocolOptions = makeHTTP2ProtocolOptions(resource.HTTP2)
+	}
+	if resource.TCPKeepalive != nil {
+		c.UpstreamConnectionOptions = &cluster.UpstreamConnectionOptions{
+			TcpKeepalive: makeTCPKeepalive(resource.TCPKeepalive),
+		}
+	}
+}
+
+func makeHTTP2ProtocolOptions(opts *v1alpha1.HTTP2Options) *core.Http2ProtocolOptions {
+	options := &core.Http2ProtocolOptions{}
+	if opts == nil {
+		return options
+	}
+	if opts.MaxConcurrentStreams != 0 {
+		options.MaxConcurrentStreams = &wrappers.UInt32Value{Value: opts.MaxConcurrentStreams}
+	}
+	if opts.InitialStreamWindowSize != 0 {
+		options.InitialStreamWindowSize = &wrappers.UInt32Value{Value: opts.InitialStreamWindowSize}
+	}
+	if opts.InitialConnectionWindowSize != 0 {
+		options.InitialConnectionWindowSize = &wrappers.UInt32Value{Value: opts.InitialConnectionWindowSize}
+	}
+	if opts.KeepaliveInterval != 0 {
+		options.ConnectionKeepalive = &core.KeepaliveSettings{
+			Interval: ptypes.DurationProto(opts.KeepaliveInterval),
+			Timeout:  ptypes.DurationProto(opts.KeepaliveTimeout),
+		}
+	}
+	return options
+}
+
+// makeTCPKeepalive converts the durations to the whole seconds Envoy expects.
+func makeTCPKeepalive(keepalive *v1alpha1.TCPKeepalive) *core.TcpKeepalive {
+	tcp := &core.TcpKeepalive{}
+	if keepalive.Probes != 0 {
+		tcp.KeepaliveProbes = &wrappers.UInt32Value{Value: keepalive.Probes}
+	}
+	if keepalive.Time != 0 {
+		tcp.KeepaliveTime = &wrappers.UInt32Value{Value: uint32(keepalive.Time.Seconds())}
+	}
+	if keepalive.Interval != 0 {
+		tcp.KeepaliveInterval = &wrappers.UInt32Value{Value: uint32(keepalive.Interval.Seconds())}
+	}
+	return tcp
+}
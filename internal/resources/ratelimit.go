@@ -0,0 +1,149 @@
+package resources
+
+import (
+	"encoding/json"
+	"strconv"
+
+	udpa_type_v1 "github.com/cncf/udpa/go/udpa/type/v1"
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	ratelimit_config "github.com/envoyproxy/go-control-plane/envoy/config/ratelimit/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	ratelimit "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ratelimit/v3"
+	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	structpb "github.com/golang/protobuf/ptypes/struct"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+	"github.com/weinong/envoy-control-plane/internal/utils"
+)
+
+const (
+	localRateLimit = "envoy.filters.http.local_ratelimit"
+	// go-control-plane does not ship the local rate limit protos yet, so its
+	// config is sent as a TypedStruct that Envoy converts into this type
+	localRateLimitTypeURL = "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit"
+	localRateLimitPrefix  = "http_local_rate_limiter"
+)
+
+// makeLocalRateLimitFilter installs the local rate limit filter without a
+// token bucket of its own, so that only the routes with a per route config
+// are rate limited.
+func makeLocalRateLimitFilter() *hcm.HttpFilter {
+	return &hcm.HttpFilter{
+		Name: localRateLimit,
+		ConfigType: &hcm.HttpFilter_TypedConfig{
+			TypedConfig: makeLocalRateLimitConfig(map[string]interface{}{
+				"stat_prefix": localRateLimitPrefix,
+			}),
+		},
+	}
+}
+
+// makeLocalRateLimitPerRoute enables and enforces the token bucket of a
+// route. Envoy leaves the filter disabled for all requests by default.
+func makeLocalRateLimitPerRoute(rl *v1alpha1.LocalRateLimit) *any.Any {
+	tokensPerFill := rl.TokensPerFill
+	if tokensPerFill == 0 {
+		tokensPerFill = rl.MaxTokens
+	}
+	allRequests := func(runtimeKey string) map[string]interface{} {
+		return map[string]interface{}{
+			"runtime_key": runtimeKey,
+			"default_value": map[string]interface{}{
+				"numerator":   100,
+				"denominator": "HUNDRED",
+			},
+		}
+	}
+
+	return makeLocalRateLimitConfig(map[string]interface{}{
+		"stat_prefix": localRateLimitPrefix,
+		"token_bucket": map[string]interface{}{
+			"max_tokens":      rl.MaxTokens,
+			"tokens_per_fill": tokensPerFill,
+			// durations are written in seconds in the JSON form of protobuf
+			"fill_interval": strconv.FormatFloat(rl.FillInterval.Seconds(), 'f', -1, 64) + "s",
+		},
+		"filter_enabled":  allRequests("local_rate_limit_enabled"),
+		"filter_enforced": allRequests("local_rate_limit_enforced"),
+	})
+}
+
+func makeLocalRateLimitConfig(config map[string]interface{}) *any.Any {
+	b, err := json.Marshal(config)
+	if err != nil {
+		panic(err)
+	}
+	value := &structpb.Struct{}
+	if err := jsonpb.UnmarshalString(string(b), value); err != nil {
+		panic(err)
+	}
+
+	return utils.MustMarshalAny(&udpa_type_v1.TypedStruct{
+		TypeUrl: localRateLimitTypeURL,
+		Value:   value,
+	})
+}
+
+func makeGlobalRateLimitFilter(cfg *v1alpha1.GlobalRateLimit) *hcm.HttpFilter {
+	rl := &ratelimit.RateLimit{
+		Domain:          cfg.Domain,
+		FailureModeDeny: cfg.FailureModeDeny,
+		RateLimitService: &ratelimit_config.RateLimitServiceConfig{
+			GrpcService: &core.GrpcService{
+				TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
+					EnvoyGrpc: &core.GrpcService_EnvoyGrpc{ClusterName: cfg.Cluster},
+				},
+			},
+			TransportApiVersion: core.ApiVersion_V3,
+		},
+	}
+	if cfg.Timeout != 0 {
+		rl.Timeout = ptypes.DurationProto(cfg.Timeout)
+	}
+
+	return &hcm.HttpFilter{
+		Name: wellknown.HTTPRateLimit,
+		ConfigType: &hcm.HttpFilter_TypedConfig{
+			TypedConfig: utils.MustMarshalAny(rl),
+		},
+	}
+}
+
+func makeRateLimit(rl v1alpha1.RateLimit) *route.RateLimit {
+	var actions []*route.RateLimit_Action
+
+	for _, a := range rl.Actions {
+		action := &route.RateLimit_Action{}
+		switch {
+		case a.GenericKey != "":
+			action.ActionSpecifier = &route.RateLimit_Action_GenericKey_{
+				GenericKey: &route.RateLimit_Action_GenericKey{
+					DescriptorValue: a.GenericKey,
+				},
+			}
+		case a.RequestHeader != nil:
+			action.ActionSpecifier = &route.RateLimit_Action_RequestHeaders_{
+				RequestHeaders: &route.RateLimit_Action_RequestHeaders{
+					HeaderName:    a.RequestHeader.HeaderName,
+					DescriptorKey: a.RequestHeader.DescriptorKey,
+				},
+			}
+		case a.RemoteAddress:
+			action.ActionSpecifier = &route.RateLimit_Action_RemoteAddress_{
+				RemoteAddress: &route.RateLimit_Action_RemoteAddress{},
+			}
+		default:
+			action.ActionSpecifier = &route.RateLimit_Action_DestinationCluster_{
+				DestinationCluster: &route.RateLimit_Action_DestinationCluster{},
+			}
+		}
+		actions = append(actions, action)
+	}
+
+	return &route.RateLimit{
+		Actions: actions,
+	}
+}
@@ -0,0 +1,60 @@
+package resources
+
+import (
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+const defaultRedirectResponseCode = 301
+
+var redirectResponseCodes = map[uint32]route.RedirectAction_RedirectResponseCode{
+	301: route.RedirectAction_MOVED_PERMANENTLY,
+	302: route.RedirectAction_FOUND,
+	303: route.RedirectAction_SEE_OTHER,
+	307: route.RedirectAction_TEMPORARY_REDIRECT,
+	308: route.RedirectAction_PERMANENT_REDIRECT,
+}
+
+func makeRedirectAction(redirect *v1alpha1.Redirect) *route.RedirectAction {
+	action := &route.RedirectAction{
+		HostRedirect: redirect.Host,
+		PortRedirect: redirect.Port,
+		StripQuery:   redirect.StripQuery,
+	}
+	if redirect.HTTPSRedirect {
+		action.SchemeRewriteSpecifier = &route.RedirectAction_HttpsRedirect{HttpsRedirect: true}
+	}
+	switch {
+	case redirect.Path != "":
+		action.PathRewriteSpecifier = &route.RedirectAction_PathRedirect{PathRedirect: redirect.Path}
+	case redirect.PrefixRewrite != "":
+		action.PathRewriteSpecifier = &route.RedirectAction_PrefixRewrite{PrefixRewrite: redirect.PrefixRewrite}
+	}
+	code := redirect.ResponseCode
+	if code == 0 {
+		code = defaultRedirectResponseCode
+	}
+	action.ResponseCode = redirectResponseCodes[code]
+	return action
+}
+
+func makeDirectResponseAction(response *v1alpha1.DirectResponse) *route.DirectResponseAction {
+	action := &route.DirectResponseAction{
+		Status: response.Status,
+	}
+	if response.Body != "" {
+		action.Body = &core.DataSource{
+			Specifier: &core.DataSource_InlineString{InlineString: response.Body},
+		}
+	}
+	return action
+}
+
+func makeRegexRewrite(rewrite *v1alpha1.RegexRewrite) *matcher.RegexMatchAndSubstitute {
+	return &matcher.RegexMatchAndSubstitute{
+		Pattern:      makeRegexMatcher(rewrite.Pattern),
+		Substitution: rewrite.Substitution,
+	}
+}
@@ -5,53 +5,136 @@ import (
 	"time"
 
 	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/any"
+	"github.com/golang/protobuf/ptypes/wrappers"
 
-	accesslog "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
 	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	endpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	listener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
-	envoy_file_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/file/v3"
-	extauth "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/ext_authz/v3"
+	tls_inspector "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/tls_inspector/v3"
 	hcm "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
-	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/wellknown"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
 	"github.com/weinong/envoy-control-plane/internal/utils"
 )
 
 const (
 	UpstreamHost = "www.envoyproxy.io"
 	UpstreamPort = 80
+
+	defaultConnectTimeout = 5 * time.Second
 )
 
 type Listener struct {
-	Name       string
-	Address    string
-	Port       uint32
-	RouteNames []string
-	CertFile   string
-	KeyFile    string
+	Name            string
+	Address         string
+	Port            uint32
+	Protocol        v1alpha1.ListenerProtocol
+	ExtAuthz        *v1alpha1.ExtAuthzFilter
+	GlobalRateLimit *v1alpha1.GlobalRateLimit
+	AccessLogs      []v1alpha1.AccessLog
+	FilterChains    []FilterChain
 }
 
-type Route struct {
+// FilterChain serves the connections whose SNI matches ServerNames, or all
+// other connections when ServerNames is empty.
+type FilterChain struct {
 	Name        string
-	Prefix      string
-	Header      string
-	HostRewrite string
+	ServerNames []string
+	// RouteConfigName is only set on http listeners, while Cluster and
+	// WeightedClusters are only set on tcp and tls-passthrough listeners
+	RouteConfigName string
+	// CORS, LocalRateLimit and Fault install the cors, local rate limit and
+	// fault filters for the route configuration
+	CORS             bool
+	LocalRateLimit   bool
+	Fault            bool
+	Cluster          string
+	WeightedClusters []v1alpha1.WeightedCluster
+	TLS              *v1alpha1.DownstreamTLS
+	// CertSecret and CASecret name the SDS secrets holding the serving
+	// certificate and the CA used to validate client certificates
+	CertSecret string
+	CASecret   string
+}
+
+// RouteConfig holds the virtual hosts served by one route configuration.
+type RouteConfig struct {
+	Name               string
+	VirtualHosts       []VirtualHost
+	HeaderManipulation v1alpha1.HeaderManipulation
+}
+
+type VirtualHost struct {
+	Name               string
+	Domains            []string
+	Routes             []Route
+	Cors               *v1alpha1.CorsPolicy
+	HeaderManipulation v1alpha1.HeaderManipulation
+}
+
+type Route struct {
+	Name                  string
+	Prefix                string
+	Path                  string
+	SafeRegex             string
+	Headers               []v1alpha1.HeaderMatcher
+	QueryParameters       []v1alpha1.QueryParameterMatcher
+	HostRewrite           string
+	Cluster               string
+	WeightedClusters      []v1alpha1.WeightedCluster
+	ClusterHeader         string
+	Timeout               time.Duration
+	IdleTimeout           time.Duration
+	RetryPolicy           *v1alpha1.RetryPolicy
+	HashPolicies          []v1alpha1.HashPolicy
+	DisableExtAuthz       bool
+	Cors                  *v1alpha1.CorsPolicy
+	LocalRateLimit        *v1alpha1.LocalRateLimit
+	RateLimits            []v1alpha1.RateLimit
+	Fault                 *v1alpha1.FaultInjection
+	RequestMirrorPolicies []v1alpha1.RequestMirrorPolicy
+	HeaderManipulation    v1alpha1.HeaderManipulation
+	PrefixRewrite         string
+	RegexRewrite          *v1alpha1.RegexRewrite
+	Redirect              *v1alpha1.Redirect
+	DirectResponse        *v1alpha1.DirectResponse
 }
 
 type Cluster struct {
-	Name          string
-	IsHTTPS       bool
-	DiscoveryType string
-	Endpoints     []Endpoint
+	Name             string
+	TLS              *v1alpha1.UpstreamTLS
+	ClientCertSecret string
+	CASecret         string
+	DiscoveryType    string
+	Endpoints        []Endpoint
+	ConnectTimeout   time.Duration
+	RetryBudget      *v1alpha1.RetryBudget
+	LbPolicy         v1alpha1.LbPolicy
+	LeastRequest     *v1alpha1.LeastRequestLbConfig
+	RingHash         *v1alpha1.RingHashLbConfig
+	Maglev           *v1alpha1.MaglevLbConfig
+	HealthCheck      *v1alpha1.HealthCheck
+	CircuitBreakers  *v1alpha1.CircuitBreakers
+	OutlierDetection *v1alpha1.OutlierDetection
+	Protocol         v1alpha1.UpstreamProtocol
+	HTTP2            *v1alpha1.HTTP2Options
+	TCPKeepalive     *v1alpha1.TCPKeepalive
+	ZoneAwareRouting *v1alpha1.ZoneAwareRouting
 }
 
 type Endpoint struct {
 	UpstreamHost string
 	UpstreamPort uint32
+	Region       string
+	Zone         string
+	SubZone      string
+	Priority     uint32
+	Weight       uint32
+	HealthStatus v1alpha1.HealthStatus
 }
 
 func (resource Cluster) MakeCluster() *cluster.Cluster {
@@ -63,22 +146,45 @@ func (resource Cluster) MakeCluster() *cluster.Cluster {
 		clusterType = &cluster.Cluster_Type{Type: cluster.Cluster_LOGICAL_DNS}
 	case "Static":
 		clusterType = &cluster.Cluster_Type{Type: cluster.Cluster_STATIC}
+	case "EDS":
+		clusterType = &cluster.Cluster_Type{Type: cluster.Cluster_EDS}
 	default:
 		panic(fmt.Sprintf("unknown cluster discovery type: %s", resource.DiscoveryType))
 	}
+	connectTimeout := resource.ConnectTimeout
+	if connectTimeout == 0 {
+		connectTimeout = defaultConnectTimeout
+	}
 	c := &cluster.Cluster{
 		Name:                 resource.Name,
-		ConnectTimeout:       ptypes.DurationProto(5 * time.Second),
+		ConnectTimeout:       ptypes.DurationProto(connectTimeout),
 		ClusterDiscoveryType: clusterType,
-		LbPolicy:             cluster.Cluster_ROUND_ROBIN,
-		LoadAssignment:       makeEndpoint(resource.Name, resource.Endpoints),
 		DnsLookupFamily:      cluster.Cluster_V4_ONLY,
 	}
-	if resource.IsHTTPS {
+	setLbPolicy(c, resource)
+	setProtocolOptions(c, resource)
+	if resource.ZoneAwareRouting != nil {
+		c.CommonLbConfig = makeZoneAwareLbConfig(resource.ZoneAwareRouting)
+	}
+	if resource.HealthCheck != nil {
+		c.HealthChecks = []*core.HealthCheck{makeHealthCheck(resource.HealthCheck)}
+	}
+	if resource.DiscoveryType == "EDS" {
+		c.EdsClusterConfig = makeEDSCluster()
+	} else {
+		c.LoadAssignment = MakeEndpoint(resource.Name, resource.Endpoints)
+	}
+	if resource.CircuitBreakers != nil || resource.RetryBudget != nil {
+		c.CircuitBreakers = makeCircuitBreakers(resource.CircuitBreakers, resource.RetryBudget)
+	}
+	if resource.OutlierDetection != nil {
+		c.OutlierDetection = makeOutlierDetection(resource.OutlierDetection)
+	}
+	if resource.TLS != nil {
 		c.TransportSocket = &core.TransportSocket{
 			Name: wellknown.TransportSocketTls,
 			ConfigType: &core.TransportSocket_TypedConfig{
-				TypedConfig: utils.MustMarshalAny(&envoy_tls_v3.UpstreamTlsContext{}),
+				TypedConfig: utils.MustMarshalAny(makeUpstreamTLSContext(resource.TLS, resource.ClientCertSecret, resource.CASecret)),
 			},
 		}
 	}
@@ -91,11 +197,26 @@ func makeEDSCluster() *cluster.Cluster_EdsClusterConfig {
 	}
 }
 
-func makeEndpoint(clusterName string, eps []Endpoint) *endpoint.ClusterLoadAssignment {
-	var endpoints []*endpoint.LbEndpoint
+// MakeEndpoint builds the ClusterLoadAssignment for a cluster. It is either
+// inlined into a non-EDS cluster or served on its own over EDS. Endpoints
+// sharing a locality and priority are grouped into one LocalityLbEndpoints,
+// in the order they first appear.
+func MakeEndpoint(clusterName string, eps []Endpoint) *endpoint.ClusterLoadAssignment {
+	var localities []*endpoint.LocalityLbEndpoints
+	index := make(map[localityKey]int)
 
 	for _, e := range eps {
-		endpoints = append(endpoints, &endpoint.LbEndpoint{
+		key := localityKey{e.Region, e.Zone, e.SubZone, e.Priority}
+		i, ok := index[key]
+		if !ok {
+			i = len(localities)
+			index[key] = i
+			localities = append(localities, &endpoint.LocalityLbEndpoints{
+				Locality: makeLocality(e),
+				Priority: e.Priority,
+			})
+		}
+		lbEndpoint := &endpoint.LbEndpoint{
 			HostIdentifier: &endpoint.LbEndpoint_Endpoint{
 				Endpoint: &endpoint.Endpoint{
 					Address: &core.Address{
@@ -111,151 +232,285 @@ func makeEndpoint(clusterName string, eps []Endpoint) *endpoint.ClusterLoadAssig
 					},
 				},
 			},
-		})
+			HealthStatus: healthStatuses[e.HealthStatus],
+		}
+		if e.Weight != 0 {
+			lbEndpoint.LoadBalancingWeight = &wrappers.UInt32Value{Value: e.Weight}
+		}
+		localities[i].LbEndpoints = append(localities[i].LbEndpoints, lbEndpoint)
 	}
 
 	return &endpoint.ClusterLoadAssignment{
 		ClusterName: clusterName,
-		Endpoints: []*endpoint.LocalityLbEndpoints{{
-			LbEndpoints: endpoints,
-		}},
+		Endpoints:   localities,
 	}
 }
 
-func MakeRoute(routeKey string, routes []Route) *route.RouteConfiguration {
+func MakeRoute(rc RouteConfig) *route.RouteConfiguration {
+	var vhs []*route.VirtualHost
+
+	for _, vh := range rc.VirtualHosts {
+		h := vh.HeaderManipulation
+		v := &route.VirtualHost{
+			Name:                    vh.Name,
+			Domains:                 vh.Domains,
+			Routes:                  makeRoutes(vh.Routes),
+			RequestHeadersToAdd:     makeHeaderValueOptions(h.RequestHeadersToAdd),
+			RequestHeadersToRemove:  h.RequestHeadersToRemove,
+			ResponseHeadersToAdd:    makeHeaderValueOptions(h.ResponseHeadersToAdd),
+			ResponseHeadersToRemove: h.ResponseHeadersToRemove,
+		}
+		if vh.Cors != nil {
+			v.Cors = makeCorsPolicy(vh.Cors)
+		}
+		vhs = append(vhs, v)
+	}
+
+	h := rc.HeaderManipulation
+	return &route.RouteConfiguration{
+		Name:                    rc.Name,
+		VirtualHosts:            vhs,
+		RequestHeadersToAdd:     makeHeaderValueOptions(h.RequestHeadersToAdd),
+		RequestHeadersToRemove:  h.RequestHeadersToRemove,
+		ResponseHeadersToAdd:    makeHeaderValueOptions(h.ResponseHeadersToAdd),
+		ResponseHeadersToRemove: h.ResponseHeadersToRemove,
+	}
+}
+
+func makeRoutes(routes []Route) []*route.Route {
 	var rts []*route.Route
 
 	for _, r := range routes {
-		action := &route.Route_Route{}
-		action.Route = &route.RouteAction{
-			ClusterSpecifier: &route.RouteAction_ClusterHeader{
-				ClusterHeader: routeKey,
-			},
+		h := r.HeaderManipulation
+		rt := &route.Route{
+			//Name: r.Name,
+			Match:                   makeRouteMatch(r),
+			RequestHeadersToAdd:     makeHeaderValueOptions(h.RequestHeadersToAdd),
+			RequestHeadersToRemove:  h.RequestHeadersToRemove,
+			ResponseHeadersToAdd:    makeHeaderValueOptions(h.ResponseHeadersToAdd),
+			ResponseHeadersToRemove: h.ResponseHeadersToRemove,
 		}
-		if r.HostRewrite != "" {
-			action.Route.HostRewriteSpecifier = &route.RouteAction_HostRewriteLiteral{
-				HostRewriteLiteral: r.HostRewrite,
+		switch {
+		case r.Redirect != nil:
+			rt.Action = &route.Route_Redirect{
+				Redirect: makeRedirectAction(r.Redirect),
+			}
+		case r.DirectResponse != nil:
+			rt.Action = &route.Route_DirectResponse{
+				DirectResponse: makeDirectResponseAction(r.DirectResponse),
+			}
+		default:
+			rt.Action = &route.Route_Route{
+				Route: makeRouteAction(r),
 			}
 		}
-		rts = append(rts, &route.Route{
-			//Name: r.Name,
-			Match: &route.RouteMatch{
-				PathSpecifier: &route.RouteMatch_Prefix{Prefix: r.Prefix},
-			},
-			Action: action,
-		})
+		if r.DisableExtAuthz || r.LocalRateLimit != nil || r.Fault != nil {
+			rt.TypedPerFilterConfig = make(map[string]*any.Any)
+		}
+		if r.DisableExtAuthz {
+			rt.TypedPerFilterConfig[wellknown.HTTPExternalAuthorization] = makeExtAuthzDisabled()
+		}
+		if r.LocalRateLimit != nil {
+			rt.TypedPerFilterConfig[localRateLimit] = makeLocalRateLimitPerRoute(r.LocalRateLimit)
+		}
+		if r.Fault != nil {
+			rt.TypedPerFilterConfig[wellknown.Fault] = makeFaultPerRoute(r.Fault)
+		}
+		rts = append(rts, rt)
 	}
 
-	return &route.RouteConfiguration{
-		Name: "listener_0",
-		VirtualHosts: []*route.VirtualHost{{
-			Name:    "local_service",
-			Domains: []string{"*"},
-			Routes:  rts,
-		}},
+	return rts
+}
+
+// makeRouteAction forwards the requests of a route to its upstream clusters.
+func makeRouteAction(r Route) *route.RouteAction {
+	action := &route.RouteAction{}
+	switch {
+	case r.Cluster != "":
+		action.ClusterSpecifier = &route.RouteAction_Cluster{
+			Cluster: r.Cluster,
+		}
+	case len(r.WeightedClusters) > 0:
+		action.ClusterSpecifier = &route.RouteAction_WeightedClusters{
+			WeightedClusters: makeWeightedClusters(r.WeightedClusters),
+		}
+	default:
+		action.ClusterSpecifier = &route.RouteAction_ClusterHeader{
+			ClusterHeader: r.ClusterHeader,
+		}
+	}
+	if r.HostRewrite != "" {
+		action.HostRewriteSpecifier = &route.RouteAction_HostRewriteLiteral{
+			HostRewriteLiteral: r.HostRewrite,
+		}
+	}
+	action.PrefixRewrite = r.PrefixRewrite
+	if r.RegexRewrite != nil {
+		action.RegexRewrite = makeRegexRewrite(r.RegexRewrite)
+	}
+	if r.Timeout != 0 {
+		action.Timeout = ptypes.DurationProto(r.Timeout)
+	}
+	if r.IdleTimeout != 0 {
+		action.IdleTimeout = ptypes.DurationProto(r.IdleTimeout)
+	}
+	if r.RetryPolicy != nil {
+		action.RetryPolicy = makeRetryPolicy(r.RetryPolicy)
 	}
+	if r.Cors != nil {
+		action.Cors = makeCorsPolicy(r.Cors)
+	}
+	for _, rl := range r.RateLimits {
+		action.RateLimits = append(action.RateLimits, makeRateLimit(rl))
+	}
+	for _, m := range r.RequestMirrorPolicies {
+		action.RequestMirrorPolicies = append(action.RequestMirrorPolicies, makeRequestMirrorPolicy(m))
+	}
+	for _, h := range r.HashPolicies {
+		action.HashPolicy = append(action.HashPolicy, makeHashPolicy(h))
+	}
+	return action
 }
 
-func MakeHTTPListener(listenerName, route, address string, port uint32, certFile, keyFile string) *listener.Listener {
-	// HTTP filter configuration
-	manager := &hcm.HttpConnectionManager{
-		CodecType:  hcm.HttpConnectionManager_AUTO,
-		StatPrefix: "http",
-		RouteSpecifier: &hcm.HttpConnectionManager_Rds{
-			Rds: &hcm.Rds{
-				ConfigSource:    makeConfigSource(),
-				RouteConfigName: "listener_0",
-			},
-		},
-		AccessLog: []*accesslog.AccessLog{
-			{
-				Name: wellknown.FileAccessLog,
-				ConfigType: &accesslog.AccessLog_TypedConfig{
-					TypedConfig: utils.MustMarshalAny(&envoy_file_v3.FileAccessLog{
-						Path: "/dev/stdout",
-					}),
-				},
-			},
-		},
-		HttpFilters: []*hcm.HttpFilter{
-			{
-				Name: wellknown.HTTPExternalAuthorization,
-				ConfigType: &hcm.HttpFilter_TypedConfig{
-					TypedConfig: utils.MustMarshalAny(&extauth.ExtAuthz{
-						Services: &extauth.ExtAuthz_GrpcService{
-							GrpcService: &core.GrpcService{
-								TargetSpecifier: &core.GrpcService_EnvoyGrpc_{
-									EnvoyGrpc: &core.GrpcService_EnvoyGrpc{ClusterName: "ext-auth"},
-								},
-							},
-						},
-						TransportApiVersion: core.ApiVersion_V3,
-						// clear route cache to allow ext authz to affect routing decision
-						ClearRouteCache: true}),
-				},
-			},
-			{
-				Name: wellknown.Router,
-			},
-		},
-		UpgradeConfigs: []*hcm.HttpConnectionManager_UpgradeConfig{
-			{
-				UpgradeType: "websocket",
-			},
-			{
-				UpgradeType: "spdy/3.1",
-			},
-		},
+func makeWeightedClusters(weightedClusters []v1alpha1.WeightedCluster) *route.WeightedCluster {
+	var clusters []*route.WeightedCluster_ClusterWeight
+	var total uint32
+
+	for _, wc := range weightedClusters {
+		clusters = append(clusters, &route.WeightedCluster_ClusterWeight{
+			Name:   wc.Name,
+			Weight: &wrappers.UInt32Value{Value: wc.Weight},
+		})
+		total += wc.Weight
 	}
 
+	return &route.WeightedCluster{
+		Clusters:    clusters,
+		TotalWeight: &wrappers.UInt32Value{Value: total},
+	}
+}
+
+// MakeListener builds a listener with one filter chain per FilterChain. http
+// listeners route requests through RDS while tcp and tls-passthrough
+// listeners hand the connection to a TCP proxy.
+func MakeListener(resource Listener) *listener.Listener {
 	l := &listener.Listener{
-		Name: listenerName,
+		Name: resource.Name,
 		Address: &core.Address{
 			Address: &core.Address_SocketAddress{
 				SocketAddress: &core.SocketAddress{
 					Protocol: core.SocketAddress_TCP,
-					Address:  address,
+					Address:  resource.Address,
 					PortSpecifier: &core.SocketAddress_PortValue{
-						PortValue: port,
+						PortValue: resource.Port,
 					},
 				},
 			},
 		},
-		FilterChains: []*listener.FilterChain{{
-			Filters: []*listener.Filter{{
+	}
+
+	inspectSNI := false
+	for _, fc := range resource.FilterChains {
+		var filter *listener.Filter
+		if resource.Protocol == v1alpha1.HTTP {
+			filter = &listener.Filter{
 				Name: wellknown.HTTPConnectionManager,
 				ConfigType: &listener.Filter_TypedConfig{
-					TypedConfig: utils.MustMarshalAny(manager),
+					TypedConfig: utils.MustMarshalAny(makeHTTPConnectionManager(resource, fc)),
 				},
-			}},
-		}},
+			}
+		} else {
+			filter = &listener.Filter{
+				Name: wellknown.TCPProxy,
+				ConfigType: &listener.Filter_TypedConfig{
+					TypedConfig: utils.MustMarshalAny(makeTCPProxy(resource, fc)),
+				},
+			}
+		}
+		chain := &listener.FilterChain{
+			Name:    fc.Name,
+			Filters: []*listener.Filter{filter},
+		}
+		if len(fc.ServerNames) > 0 {
+			chain.FilterChainMatch = &listener.FilterChainMatch{
+				ServerNames: fc.ServerNames,
+			}
+			inspectSNI = true
+		}
+		if fc.TLS != nil {
+			chain.TransportSocket = &core.TransportSocket{
+				Name: wellknown.TransportSocketTls,
+				ConfigType: &core.TransportSocket_TypedConfig{
+					TypedConfig: utils.MustMarshalAny(makeDownstreamTLSContext(fc.TLS, fc.CertSecret, fc.CASecret)),
+				},
+			}
+		}
+		l.FilterChains = append(l.FilterChains, chain)
 	}
 
-	if certFile != "" && keyFile != "" {
-		l.FilterChains[0].TransportSocket = &core.TransportSocket{
-			Name: wellknown.TransportSocketTls,
-			ConfigType: &core.TransportSocket_TypedConfig{
-				TypedConfig: utils.MustMarshalAny(&envoy_tls_v3.DownstreamTlsContext{
-					CommonTlsContext: &envoy_tls_v3.CommonTlsContext{
-						TlsCertificates: []*envoy_tls_v3.TlsCertificate{
-							{
-								PrivateKey: &core.DataSource{
-									Specifier: &core.DataSource_Filename{Filename: keyFile},
-								},
-								CertificateChain: &core.DataSource{
-									Specifier: &core.DataSource_Filename{Filename: certFile},
-								},
-							},
-						},
-					},
-				}),
+	// the SNI of the client hello is only known to filter chain matching
+	// after the tls_inspector has looked at it
+	if inspectSNI {
+		l.ListenerFilters = []*listener.ListenerFilter{{
+			Name: wellknown.TlsInspector,
+			ConfigType: &listener.ListenerFilter_TypedConfig{
+				TypedConfig: utils.MustMarshalAny(&tls_inspector.TlsInspector{}),
 			},
-		}
+		}}
 	}
 
 	return l
 }
 
+func makeHTTPConnectionManager(resource Listener, fc FilterChain) *hcm.HttpConnectionManager {
+	var httpFilters []*hcm.HttpFilter
+	// preflight requests are answered by the cors filter before they need
+	// to be authorized
+	if fc.CORS {
+		httpFilters = append(httpFilters, &hcm.HttpFilter{
+			Name: wellknown.CORS,
+		})
+	}
+	if fc.LocalRateLimit {
+		httpFilters = append(httpFilters, makeLocalRateLimitFilter())
+	}
+	if extAuthzEnabled(resource.ExtAuthz) {
+		httpFilters = append(httpFilters, makeExtAuthzFilter(resource.ExtAuthz))
+	}
+	if resource.GlobalRateLimit != nil {
+		httpFilters = append(httpFilters, makeGlobalRateLimitFilter(resource.GlobalRateLimit))
+	}
+	if fc.Fault {
+		httpFilters = append(httpFilters, makeFaultFilter())
+	}
+	// the router has to be the last filter
+	httpFilters = append(httpFilters, &hcm.HttpFilter{
+		Name: wellknown.Router,
+	})
+
+	// HTTP filter configuration
+	return &hcm.HttpConnectionManager{
+		CodecType:  hcm.HttpConnectionManager_AUTO,
+		StatPrefix: "http",
+		RouteSpecifier: &hcm.HttpConnectionManager_Rds{
+			Rds: &hcm.Rds{
+				ConfigSource:    makeConfigSource(),
+				RouteConfigName: fc.RouteConfigName,
+			},
+		},
+		AccessLog:   makeAccessLogs(resource),
+		HttpFilters: httpFilters,
+		UpgradeConfigs: []*hcm.HttpConnectionManager_UpgradeConfig{
+			{
+				UpgradeType: "websocket",
+			},
+			{
+				UpgradeType: "spdy/3.1",
+			},
+		},
+	}
+}
+
 func makeConfigSource() *core.ConfigSource {
 	source := &core.ConfigSource{}
 	source.ResourceApiVersion = resource.DefaultAPIVersion
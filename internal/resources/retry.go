@@ -0,0 +1,54 @@
+package resources
+
+import (
+	cluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	route "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	envoy_type_v3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+const (
+	defaultRetryOn    = "gateway-error,connect-failure,refused-stream"
+	defaultNumRetries = 1
+)
+
+func makeRetryPolicy(policy *v1alpha1.RetryPolicy) *route.RetryPolicy {
+	retryOn := policy.RetryOn
+	if retryOn == "" {
+		retryOn = defaultRetryOn
+	}
+	numRetries := policy.NumRetries
+	if numRetries == 0 {
+		numRetries = defaultNumRetries
+	}
+
+	p := &route.RetryPolicy{
+		RetryOn:    retryOn,
+		NumRetries: &wrappers.UInt32Value{Value: numRetries},
+	}
+	if policy.PerTryTimeout != 0 {
+		p.PerTryTimeout = ptypes.DurationProto(policy.PerTryTimeout)
+	}
+	if policy.Backoff != nil {
+		p.RetryBackOff = &route.RetryPolicy_RetryBackOff{
+			BaseInterval: ptypes.DurationProto(policy.Backoff.BaseInterval),
+		}
+		if policy.Backoff.MaxInterval != 0 {
+			p.RetryBackOff.MaxInterval = ptypes.DurationProto(policy.Backoff.MaxInterval)
+		}
+	}
+
+	return p
+}
+
+func makeRetryBudget(budget *v1alpha1.RetryBudget) *cluster.CircuitBreakers_Thresholds_RetryBudget {
+	b := &cluster.CircuitBreakers_Thresholds_RetryBudget{
+		MinRetryConcurrency: makeUInt32Value(budget.MinRetryConcurrency),
+	}
+	if budget.BudgetPercent != 0 {
+		b.BudgetPercent = &envoy_type_v3.Percent{Value: budget.BudgetPercent}
+	}
+	return b
+}
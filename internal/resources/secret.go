@@ -0,0 +1,120 @@
+package resources
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+
+	core "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+)
+
+// Secret is a certificate and private key pair, or a trusted CA bundle when
+// only TrustedCA is set, served to Envoy over SDS.
+type Secret struct {
+	Name             string
+	CertificateChain []byte
+	PrivateKey       []byte
+	TrustedCA        []byte
+}
+
+// NewCertificateSecret reads a PEM certificate chain and private key. The
+// pair is parsed so that a file caught in the middle of a rotation is
+// reported instead of being served to Envoy.
+func NewCertificateSecret(name, certFile, keyFile string) (Secret, error) {
+	cert, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		return Secret{}, fmt.Errorf("error reading certificate: %w", err)
+	}
+	key, err := ioutil.ReadFile(keyFile)
+	if err != nil {
+		return Secret{}, fmt.Errorf("error reading private key: %w", err)
+	}
+	if _, err := tls.X509KeyPair(cert, key); err != nil {
+		return Secret{}, fmt.Errorf("invalid certificate and private key: %w", err)
+	}
+
+	return Secret{
+		Name:             name,
+		CertificateChain: cert,
+		PrivateKey:       key,
+	}, nil
+}
+
+// NewValidationSecret reads a PEM bundle of trusted CA certificates, each of
+// which must parse.
+func NewValidationSecret(name, caFile string) (Secret, error) {
+	ca, err := ioutil.ReadFile(caFile)
+	if err != nil {
+		return Secret{}, fmt.Errorf("error reading trusted CA: %w", err)
+	}
+	if err := validateCABundle(ca); err != nil {
+		return Secret{}, fmt.Errorf("invalid trusted CA: %w", err)
+	}
+
+	return Secret{
+		Name:      name,
+		TrustedCA: ca,
+	}, nil
+}
+
+func validateCABundle(bundle []byte) error {
+	count := 0
+	for rest := bundle; ; {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			return fmt.Errorf("unexpected PEM block %s", block.Type)
+		}
+		if _, err := x509.ParseCertificate(block.Bytes); err != nil {
+			return err
+		}
+		count++
+	}
+	if count == 0 {
+		return fmt.Errorf("no PEM certificates found")
+	}
+	return nil
+}
+
+func (resource Secret) MakeSecret() *envoy_tls_v3.Secret {
+	if resource.TrustedCA != nil {
+		return &envoy_tls_v3.Secret{
+			Name: resource.Name,
+			Type: &envoy_tls_v3.Secret_ValidationContext{
+				ValidationContext: &envoy_tls_v3.CertificateValidationContext{
+					TrustedCa: &core.DataSource{
+						Specifier: &core.DataSource_InlineBytes{InlineBytes: resource.TrustedCA},
+					},
+				},
+			},
+		}
+	}
+
+	return &envoy_tls_v3.Secret{
+		Name: resource.Name,
+		Type: &envoy_tls_v3.Secret_TlsCertificate{
+			TlsCertificate: &envoy_tls_v3.TlsCertificate{
+				CertificateChain: &core.DataSource{
+					Specifier: &core.DataSource_InlineBytes{InlineBytes: resource.CertificateChain},
+				},
+				PrivateKey: &core.DataSource{
+					Specifier: &core.DataSource_InlineBytes{InlineBytes: resource.PrivateKey},
+				},
+			},
+		},
+	}
+}
+
+// makeSdsSecretConfig references a secret served by this control plane.
+func makeSdsSecretConfig(name string) *envoy_tls_v3.SdsSecretConfig {
+	return &envoy_tls_v3.SdsSecretConfig{
+		Name:      name,
+		SdsConfig: makeConfigSource(),
+	}
+}
@@ -0,0 +1,35 @@
+package resources
+
+import (
+	tcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+)
+
+// makeTCPProxy forwards the connections of a filter chain to either its
+// cluster or its weighted clusters.
+func makeTCPProxy(resource Listener, fc FilterChain) *tcp.TcpProxy {
+	proxy := &tcp.TcpProxy{
+		StatPrefix: "tcp",
+		AccessLog:  makeAccessLogs(resource),
+	}
+
+	if len(fc.WeightedClusters) > 0 {
+		var clusters []*tcp.TcpProxy_WeightedCluster_ClusterWeight
+		for _, wc := range fc.WeightedClusters {
+			clusters = append(clusters, &tcp.TcpProxy_WeightedCluster_ClusterWeight{
+				Name:   wc.Name,
+				Weight: wc.Weight,
+			})
+		}
+		proxy.ClusterSpecifier = &tcp.TcpProxy_WeightedClusters{
+			WeightedClusters: &tcp.TcpProxy_WeightedCluster{
+				Clusters: clusters,
+			},
+		}
+	} else {
+		proxy.ClusterSpecifier = &tcp.TcpProxy_Cluster{
+			Cluster: fc.Cluster,
+		}
+	}
+
+	return proxy
+}
@@ -0,0 +1,106 @@
+package resources
+
+import (
+	"strings"
+
+	envoy_tls_v3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	matcher "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"github.com/golang/protobuf/ptypes/wrappers"
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+var tlsVersions = map[string]envoy_tls_v3.TlsParameters_TlsProtocol{
+	"":    envoy_tls_v3.TlsParameters_TLS_AUTO,
+	"1.0": envoy_tls_v3.TlsParameters_TLSv1_0,
+	"1.1": envoy_tls_v3.TlsParameters_TLSv1_1,
+	"1.2": envoy_tls_v3.TlsParameters_TLSv1_2,
+	"1.3": envoy_tls_v3.TlsParameters_TLSv1_3,
+}
+
+// makeDownstreamTLSContext references the serving certificate and the CA
+// validating client certificates through SDS. caSecret may be empty.
+func makeDownstreamTLSContext(tls *v1alpha1.DownstreamTLS, certSecret, caSecret string) *envoy_tls_v3.DownstreamTlsContext {
+	common := &envoy_tls_v3.CommonTlsContext{
+		TlsCertificateSdsSecretConfigs: []*envoy_tls_v3.SdsSecretConfig{
+			makeSdsSecretConfig(certSecret),
+		},
+	}
+
+	if tls.MinVersion != "" || tls.MaxVersion != "" || len(tls.CipherSuites) > 0 {
+		common.TlsParams = &envoy_tls_v3.TlsParameters{
+			TlsMinimumProtocolVersion: tlsVersions[tls.MinVersion],
+			TlsMaximumProtocolVersion: tlsVersions[tls.MaxVersion],
+			CipherSuites:              tls.CipherSuites,
+		}
+	}
+
+	if caSecret != "" || len(tls.VerifyCertificateHashes) > 0 {
+		validation := &envoy_tls_v3.CertificateValidationContext{
+			MatchSubjectAltNames: makeSubjectAltNameMatchers(tls.VerifySubjectAltNames),
+		}
+		for _, hash := range tls.VerifyCertificateHashes {
+			// Envoy accepts hashes with or without colons
+			validation.VerifyCertificateHash = append(validation.VerifyCertificateHash, strings.ToLower(hash))
+		}
+
+		if caSecret != "" {
+			common.ValidationContextType = &envoy_tls_v3.CommonTlsContext_CombinedValidationContext{
+				CombinedValidationContext: &envoy_tls_v3.CommonTlsContext_CombinedCertificateValidationContext{
+					DefaultValidationContext:         validation,
+					ValidationContextSdsSecretConfig: makeSdsSecretConfig(caSecret),
+				},
+			}
+		} else {
+			common.ValidationContextType = &envoy_tls_v3.CommonTlsContext_ValidationContext{
+				ValidationContext: validation,
+			}
+		}
+	}
+
+	return &envoy_tls_v3.DownstreamTlsContext{
+		CommonTlsContext:         common,
+		RequireClientCertificate: &wrappers.BoolValue{Value: tls.RequireClientCertificate},
+	}
+}
+
+// makeUpstreamTLSContext references the client certificate and trusted CA
+// through SDS. Either secret name may be empty.
+func makeUpstreamTLSContext(tls *v1alpha1.UpstreamTLS, certSecret, caSecret string) *envoy_tls_v3.UpstreamTlsContext {
+	common := &envoy_tls_v3.CommonTlsContext{
+		AlpnProtocols: tls.ALPNProtocols,
+	}
+
+	if certSecret != "" {
+		common.TlsCertificateSdsSecretConfigs = []*envoy_tls_v3.SdsSecretConfig{
+			makeSdsSecretConfig(certSecret),
+		}
+	}
+
+	if caSecret != "" {
+		validation := &envoy_tls_v3.CertificateValidationContext{
+			MatchSubjectAltNames: makeSubjectAltNameMatchers(tls.VerifySubjectAltNames),
+		}
+		// the trusted CA comes from SDS while the SAN matchers stay inline
+		common.ValidationContextType = &envoy_tls_v3.CommonTlsContext_CombinedValidationContext{
+			CombinedValidationContext: &envoy_tls_v3.CommonTlsContext_CombinedCertificateValidationContext{
+				DefaultValidationContext:         validation,
+				ValidationContextSdsSecretConfig: makeSdsSecretConfig(caSecret),
+			},
+		}
+	}
+
+	return &envoy_tls_v3.UpstreamTlsContext{
+		CommonTlsContext: common,
+		Sni:              tls.SNI,
+	}
+}
+
+func makeSubjectAltNameMatchers(sans []string) []*matcher.StringMatcher {
+	var matchers []*matcher.StringMatcher
+	for _, san := range sans {
+		matchers = append(matchers, &matcher.StringMatcher{
+			MatchPattern: &matcher.StringMatcher_Exact{Exact: san},
+		})
+	}
+	return matchers
+}
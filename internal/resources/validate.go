@@ -0,0 +1,790 @@
+package resources
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
+)
+
+// ValidateRouteConfig checks the headers and virtual hosts of a route
+// configuration.
+func ValidateRouteConfig(rc RouteConfig) error {
+	if err := validateHeaderManipulation(rc.HeaderManipulation); err != nil {
+		return err
+	}
+	return ValidateVirtualHosts(rc.VirtualHosts)
+}
+
+// ValidateVirtualHosts checks that the virtual hosts of a route configuration
+// can be accepted by Envoy. Domains are matched case-insensitively, so domains
+// that only differ in case overlap and are rejected like exact duplicates.
+func ValidateVirtualHosts(virtualHosts []VirtualHost) error {
+	names := make(map[string]bool)
+	domains := make(map[string]string)
+
+	for _, vh := range virtualHosts {
+		if vh.Name == "" {
+			return fmt.Errorf("virtual host name is required")
+		}
+		if names[vh.Name] {
+			return fmt.Errorf("duplicate virtual host %s", vh.Name)
+		}
+		names[vh.Name] = true
+
+		if len(vh.Domains) == 0 {
+			return fmt.Errorf("virtual host %s has no domains", vh.Name)
+		}
+		for _, d := range vh.Domains {
+			if err := validateDomain(d); err != nil {
+				return fmt.Errorf("virtual host %s: %w", vh.Name, err)
+			}
+			key := strings.ToLower(d)
+			if owner, ok := domains[key]; ok {
+				return fmt.Errorf("domain %s of virtual host %s overlaps with virtual host %s", d, vh.Name, owner)
+			}
+			domains[key] = vh.Name
+		}
+
+		if vh.Cors != nil {
+			if err := validateCors(vh.Cors); err != nil {
+				return fmt.Errorf("virtual host %s: %w", vh.Name, err)
+			}
+		}
+		if err := validateHeaderManipulation(vh.HeaderManipulation); err != nil {
+			return fmt.Errorf("virtual host %s: %w", vh.Name, err)
+		}
+
+		for _, r := range vh.Routes {
+			if err := validateRoute(r); err != nil {
+				return fmt.Errorf("virtual host %s: route %s: %w", vh.Name, r.Name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateRoute checks that a route has exactly one path matcher and
+// either forwards to exactly one kind of target or answers the request itself.
+func validateRoute(r Route) error {
+	if countSet(r.Prefix != "", r.Path != "", r.SafeRegex != "") != 1 {
+		return fmt.Errorf("exactly one of prefix, path or safeRegex is required")
+	}
+	if err := validateRegex(r.SafeRegex); err != nil {
+		return err
+	}
+	if err := validateHeaderMatchers(r.Headers); err != nil {
+		return err
+	}
+	if err := validateHeaderManipulation(r.HeaderManipulation); err != nil {
+		return err
+	}
+	for _, q := range r.QueryParameters {
+		if q.Name == "" {
+			return fmt.Errorf("query parameter matcher name is required")
+		}
+		if countSet(q.Exact != "", q.Prefix != "", q.Regex != "", q.Present) != 1 {
+			return fmt.Errorf("query parameter matcher %s requires exactly one of exact, prefix, regex or present", q.Name)
+		}
+		if err := validateRegex(q.Regex); err != nil {
+			return err
+		}
+	}
+
+	targets := countSet(r.Cluster != "", len(r.WeightedClusters) > 0, r.ClusterHeader != "",
+		r.Redirect != nil, r.DirectResponse != nil)
+	if targets != 1 {
+		return fmt.Errorf("exactly one of cluster, weightedClusters, clusterHeader, redirect or directResponse is required")
+	}
+	if r.Redirect != nil || r.DirectResponse != nil {
+		if err := validateNotForwarded(r); err != nil {
+			return err
+		}
+	}
+	if r.Redirect != nil {
+		if err := validateRedirect(r.Redirect); err != nil {
+			return err
+		}
+	}
+	if d := r.DirectResponse; d != nil && (d.Status < 100 || d.Status > 599) {
+		return fmt.Errorf("direct response status %d must be between 100 and 599", d.Status)
+	}
+	if r.PrefixRewrite != "" && r.RegexRewrite != nil {
+		return fmt.Errorf("at most one of prefixRewrite or regexRewrite is allowed")
+	}
+	if rw := r.RegexRewrite; rw != nil {
+		if rw.Pattern == "" {
+			return fmt.Errorf("regex rewrite pattern is required")
+		}
+		if err := validateRegex(rw.Pattern); err != nil {
+			return err
+		}
+	}
+
+	if r.Timeout < 0 {
+		return fmt.Errorf("timeout must not be negative")
+	}
+	if r.IdleTimeout < 0 {
+		return fmt.Errorf("idleTimeout must not be negative")
+	}
+	if r.RetryPolicy != nil && r.RetryPolicy.PerTryTimeout < 0 {
+		return fmt.Errorf("retry perTryTimeout must not be negative")
+	}
+	if r.RetryPolicy != nil && r.RetryPolicy.Backoff != nil {
+		backoff := r.RetryPolicy.Backoff
+		if backoff.BaseInterval <= 0 {
+			return fmt.Errorf("retry backoff requires a positive baseInterval")
+		}
+		if backoff.MaxInterval != 0 && backoff.MaxInterval < backoff.BaseInterval {
+			return fmt.Errorf("retry backoff maxInterval must not be less than baseInterval")
+		}
+	}
+
+	for _, h := range r.HashPolicies {
+		if countSet(h.Header != "", h.Cookie != nil, h.SourceIP) != 1 {
+			return fmt.Errorf("hash policy requires exactly one of header, cookie or sourceIP")
+		}
+		if h.Cookie != nil && h.Cookie.Name == "" {
+			return fmt.Errorf("hash policy cookie name is required")
+		}
+	}
+
+	if r.Cors != nil {
+		if err := validateCors(r.Cors); err != nil {
+			return err
+		}
+	}
+
+	if rl := r.LocalRateLimit; rl != nil {
+		if rl.MaxTokens == 0 {
+			return fmt.Errorf("local rate limit requires a positive maxTokens")
+		}
+		// Envoy rejects token buckets refilled more often than every 50ms
+		if rl.FillInterval < 50*time.Millisecond {
+			return fmt.Errorf("local rate limit fillInterval must be at least 50ms")
+		}
+	}
+	for _, rl := range r.RateLimits {
+		if err := validateRateLimit(rl); err != nil {
+			return err
+		}
+	}
+
+	if r.Fault != nil {
+		if err := validateFault(r.Fault); err != nil {
+			return err
+		}
+	}
+	for _, m := range r.RequestMirrorPolicies {
+		if m.Cluster == "" {
+			return fmt.Errorf("request mirror policy cluster is required")
+		}
+		if m.Percent > 100 {
+			return fmt.Errorf("request mirror policy percent must be between 0 and 100")
+		}
+	}
+
+	return validateWeightedClusters(r.WeightedClusters)
+}
+
+func validateHeaderMatchers(headers []v1alpha1.HeaderMatcher) error {
+	for _, h := range headers {
+		if h.Name == "" {
+			return fmt.Errorf("header matcher name is required")
+		}
+		if countSet(h.Exact != "", h.Prefix != "", h.Regex != "", h.Present) != 1 {
+			return fmt.Errorf("header matcher %s requires exactly one of exact, prefix, regex or present", h.Name)
+		}
+		if err := validateRegex(h.Regex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateNotForwarded rejects the options of forwarded routes on routes
+// answered by Envoy itself, which would silently ignore them.
+func validateNotForwarded(r Route) error {
+	var option string
+	switch {
+	case r.HostRewrite != "":
+		option = "hostRewrite"
+	case r.PrefixRewrite != "":
+		option = "prefixRewrite"
+	case r.RegexRewrite != nil:
+		option = "regexRewrite"
+	case r.Timeout != 0:
+		option = "timeout"
+	case r.IdleTimeout != 0:
+		option = "idleTimeout"
+	case r.RetryPolicy != nil:
+		option = "retryPolicy"
+	case len(r.HashPolicies) > 0:
+		option = "hashPolicies"
+	case r.Cors != nil:
+		option = "cors"
+	case len(r.RateLimits) > 0:
+		option = "rateLimits"
+	case len(r.RequestMirrorPolicies) > 0:
+		option = "requestMirrorPolicies"
+	default:
+		return nil
+	}
+	return fmt.Errorf("%s requires the route to forward to a cluster", option)
+}
+
+func validateRedirect(redirect *v1alpha1.Redirect) error {
+	if redirect.Path != "" && redirect.PrefixRewrite != "" {
+		return fmt.Errorf("redirect allows at most one of path or prefixRewrite")
+	}
+	if redirect.Path != "" && !strings.HasPrefix(redirect.Path, "/") {
+		return fmt.Errorf("redirect path %s must start with /", redirect.Path)
+	}
+	if code := redirect.ResponseCode; code != 0 {
+		if _, ok := redirectResponseCodes[code]; !ok {
+			return fmt.Errorf("redirect response code %d must be one of 301, 302, 303, 307 or 308", code)
+		}
+	}
+	return nil
+}
+
+// validateHeaderManipulation rejects pseudo-headers and the host header,
+// which envoy does not allow to be modified this way.
+func validateHeaderManipulation(h v1alpha1.HeaderManipulation) error {
+	var names []string
+	for _, hv := range h.RequestHeadersToAdd {
+		names = append(names, hv.Name)
+	}
+	for _, hv := range h.ResponseHeadersToAdd {
+		names = append(names, hv.Name)
+	}
+	names = append(names, h.RequestHeadersToRemove...)
+	names = append(names, h.ResponseHeadersToRemove...)
+
+	for _, name := range names {
+		if name == "" {
+			return fmt.Errorf("header name is required")
+		}
+		if strings.HasPrefix(name, ":") || strings.EqualFold(name, "host") {
+			return fmt.Errorf("header %s cannot be modified", name)
+		}
+	}
+	return nil
+}
+
+func validateFault(f *v1alpha1.FaultInjection) error {
+	if f.Delay == nil && f.Abort == nil {
+		return fmt.Errorf("fault requires at least one of delay or abort")
+	}
+	if d := f.Delay; d != nil {
+		if countSet(d.FixedDelay > 0, d.FromHeader) != 1 {
+			return fmt.Errorf("fault delay requires exactly one of a positive fixedDelay or fromHeader")
+		}
+		if d.Percent > 100 {
+			return fmt.Errorf("fault delay percent must be between 0 and 100")
+		}
+	}
+	if a := f.Abort; a != nil {
+		if countSet(a.HTTPStatus != 0, a.FromHeader) != 1 {
+			return fmt.Errorf("fault abort requires exactly one of httpStatus or fromHeader")
+		}
+		if a.HTTPStatus != 0 && (a.HTTPStatus < 200 || a.HTTPStatus >= 600) {
+			return fmt.Errorf("fault abort httpStatus %d is not between 200 and 599", a.HTTPStatus)
+		}
+		if a.Percent > 100 {
+			return fmt.Errorf("fault abort percent must be between 0 and 100")
+		}
+	}
+	return validateHeaderMatchers(f.Headers)
+}
+
+func validateRateLimit(rl v1alpha1.RateLimit) error {
+	if len(rl.Actions) == 0 {
+		return fmt.Errorf("rate limit requires at least one action")
+	}
+	for _, a := range rl.Actions {
+		if countSet(a.GenericKey != "", a.RequestHeader != nil, a.RemoteAddress, a.DestinationCluster) != 1 {
+			return fmt.Errorf("rate limit action requires exactly one of genericKey, requestHeader, remoteAddress or destinationCluster")
+		}
+		if h := a.RequestHeader; h != nil && (h.HeaderName == "" || h.DescriptorKey == "") {
+			return fmt.Errorf("rate limit requestHeader requires headerName and descriptorKey")
+		}
+	}
+	return nil
+}
+
+func validateCors(cors *v1alpha1.CorsPolicy) error {
+	if len(cors.AllowOrigins) == 0 {
+		return fmt.Errorf("cors requires at least one allowOrigins entry")
+	}
+	for _, o := range cors.AllowOrigins {
+		if countSet(o.Exact != "", o.Regex != "") != 1 {
+			return fmt.Errorf("cors origin requires exactly one of exact or regex")
+		}
+		if err := validateRegex(o.Regex); err != nil {
+			return err
+		}
+	}
+	if cors.MaxAge < 0 {
+		return fmt.Errorf("cors maxAge must not be negative")
+	}
+	return nil
+}
+
+func validateWeightedClusters(weightedClusters []v1alpha1.WeightedCluster) error {
+	for _, wc := range weightedClusters {
+		if wc.Name == "" {
+			return fmt.Errorf("weighted cluster name is required")
+		}
+		if wc.Weight == 0 {
+			return fmt.Errorf("weighted cluster %s must have a positive weight", wc.Name)
+		}
+	}
+	return nil
+}
+
+// validateDomain accepts "*", an exact host, or a host with a single
+// leading or trailing wildcard such as "*.example.com" or "example.*".
+func validateDomain(domain string) error {
+	if domain == "" {
+		return fmt.Errorf("empty domain")
+	}
+	if domain == "*" {
+		return nil
+	}
+	if n := strings.Count(domain, "*"); n > 1 ||
+		(n == 1 && !strings.HasPrefix(domain, "*") && !strings.HasSuffix(domain, "*")) {
+		return fmt.Errorf("domain %s may only have a single leading or trailing wildcard", domain)
+	}
+	return nil
+}
+
+// ValidateCluster checks the options of a cluster that Envoy would reject.
+func ValidateCluster(c Cluster) error {
+	switch c.DiscoveryType {
+	case string(v1alpha1.LogicalDNS), v1alpha1.StrictDNS, v1alpha1.Static, v1alpha1.EDS:
+	case "":
+		return fmt.Errorf("discoveryType is required")
+	default:
+		return fmt.Errorf("unknown discoveryType %s", c.DiscoveryType)
+	}
+	if c.ConnectTimeout < 0 {
+		return fmt.Errorf("connectTimeout must not be negative")
+	}
+	if c.RetryBudget != nil && (c.RetryBudget.BudgetPercent < 0 || c.RetryBudget.BudgetPercent > 100) {
+		return fmt.Errorf("retry budgetPercent must be between 0 and 100")
+	}
+
+	if tls := c.TLS; tls != nil {
+		if (tls.CertFile == "") != (tls.KeyFile == "") {
+			return fmt.Errorf("tls certFile and keyFile must be set together")
+		}
+		if len(tls.VerifySubjectAltNames) > 0 && tls.CAFile == "" {
+			return fmt.Errorf("tls verifySubjectAltNames requires caFile")
+		}
+	}
+
+	if c.OutlierDetection != nil && c.OutlierDetection.MaxEjectionPercent > 100 {
+		return fmt.Errorf("outlier detection maxEjectionPercent must not exceed 100")
+	}
+
+	switch c.LbPolicy {
+	case "", v1alpha1.RoundRobin, v1alpha1.LeastRequest, v1alpha1.Random, v1alpha1.RingHash, v1alpha1.Maglev:
+	default:
+		return fmt.Errorf("unknown lbPolicy %s", c.LbPolicy)
+	}
+	if c.LeastRequest != nil && c.LbPolicy != v1alpha1.LeastRequest {
+		return fmt.Errorf("leastRequest requires lbPolicy %s", v1alpha1.LeastRequest)
+	}
+	if c.RingHash != nil && c.LbPolicy != v1alpha1.RingHash {
+		return fmt.Errorf("ringHash requires lbPolicy %s", v1alpha1.RingHash)
+	}
+	if c.Maglev != nil && c.LbPolicy != v1alpha1.Maglev {
+		return fmt.Errorf("maglev requires lbPolicy %s", v1alpha1.Maglev)
+	}
+	if rh := c.RingHash; rh != nil {
+		if err := validateRingHash(rh); err != nil {
+			return err
+		}
+	}
+	if m := c.Maglev; m != nil && m.TableSize != 0 {
+		if m.TableSize > maxMaglevTableSize || !isPrime(m.TableSize) {
+			return fmt.Errorf("maglev tableSize %d must be a prime not exceeding %d", m.TableSize, maxMaglevTableSize)
+		}
+	}
+
+	switch c.Protocol {
+	case "", v1alpha1.HTTP1, v1alpha1.HTTP2, v1alpha1.AutoProtocol:
+	default:
+		return fmt.Errorf("unknown protocol %s", c.Protocol)
+	}
+	if c.HTTP2 != nil {
+		if err := validateHTTP2Options(c.Protocol, c.HTTP2); err != nil {
+			return err
+		}
+	}
+	if ka := c.TCPKeepalive; ka != nil {
+		if (ka.Time != 0 && ka.Time < time.Second) || (ka.Interval != 0 && ka.Interval < time.Second) {
+			return fmt.Errorf("tcp keepalive time and interval must be at least 1s")
+		}
+	}
+
+	if za := c.ZoneAwareRouting; za != nil {
+		if za.Percent > 100 {
+			return fmt.Errorf("zone aware routing percent must not exceed 100")
+		}
+		// Envoy only routes by zone with these load balancers
+		switch c.LbPolicy {
+		case "", v1alpha1.RoundRobin, v1alpha1.LeastRequest, v1alpha1.Random:
+		default:
+			return fmt.Errorf("zone aware routing is not supported with lbPolicy %s", c.LbPolicy)
+		}
+	}
+
+	if hc := c.HealthCheck; hc != nil {
+		// unset intervals get defaults, see makeHealthCheck
+		if hc.Interval < 0 || hc.Timeout < 0 {
+			return fmt.Errorf("health check interval and timeout must not be negative")
+		}
+		// gRPC health checks are only possible over HTTP/2
+		if hc.GRPC != nil && c.Protocol == v1alpha1.HTTP1 {
+			return fmt.Errorf("grpc health check requires protocol %s or %s", v1alpha1.HTTP2, v1alpha1.AutoProtocol)
+		}
+		if countSet(hc.HTTP != nil, hc.TCP != nil, hc.GRPC != nil) != 1 {
+			return fmt.Errorf("health check requires exactly one of http, tcp or grpc")
+		}
+		if hc.HTTP != nil {
+			if hc.HTTP.Path == "" {
+				return fmt.Errorf("http health check path is required")
+			}
+			for _, r := range hc.HTTP.ExpectedStatuses {
+				if r.Start < 100 || r.End > 600 || r.Start >= r.End {
+					return fmt.Errorf("invalid expected status range [%d, %d)", r.Start, r.End)
+				}
+			}
+		}
+		if hc.TCP != nil {
+			for _, payload := range append([]string{hc.TCP.Send}, hc.TCP.Receive...) {
+				if _, err := hex.DecodeString(payload); err != nil {
+					return fmt.Errorf("tcp health check payload %q is not hex encoded", payload)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidateEndpoints checks the health statuses of the endpoints of a cluster
+// and that their priorities are contiguous from 0, as Envoy requires.
+func ValidateEndpoints(endpoints []Endpoint) error {
+	priorities := make(map[uint32]bool)
+	var maxPriority uint32
+	for _, e := range endpoints {
+		if _, ok := healthStatuses[e.HealthStatus]; !ok {
+			return fmt.Errorf("endpoint %s:%d has unknown healthStatus %s", e.UpstreamHost, e.UpstreamPort, e.HealthStatus)
+		}
+		if e.SubZone != "" && e.Zone == "" {
+			return fmt.Errorf("endpoint %s:%d has a subZone but no zone", e.UpstreamHost, e.UpstreamPort)
+		}
+		priorities[e.Priority] = true
+		if e.Priority > maxPriority {
+			maxPriority = e.Priority
+		}
+	}
+	for p := uint32(0); p < maxPriority; p++ {
+		if !priorities[p] {
+			return fmt.Errorf("endpoint priority %d is set but priority %d is not", maxPriority, p)
+		}
+	}
+	return nil
+}
+
+// Limits and defaults Envoy applies to the RING_HASH and MAGLEV load
+// balancers
+const (
+	defaultMinimumRingSize = 1024
+	maxRingSize            = 8388608
+	maxMaglevTableSize     = 5000011
+)
+
+func validateRingHash(rh *v1alpha1.RingHashLbConfig) error {
+	if rh.MinimumRingSize > maxRingSize || rh.MaximumRingSize > maxRingSize {
+		return fmt.Errorf("ringHash ring sizes must not exceed %d", maxRingSize)
+	}
+	minimum, maximum := rh.MinimumRingSize, rh.MaximumRingSize
+	if minimum == 0 {
+		minimum = defaultMinimumRingSize
+	}
+	if maximum == 0 {
+		maximum = maxRingSize
+	}
+	if minimum > maximum {
+		return fmt.Errorf("ringHash minimumRingSize %d must not exceed maximumRingSize %d", minimum, maximum)
+	}
+	return nil
+}
+
+func isPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	for d := uint64(2); d*d <= n; d++ {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// maxHTTP2SettingValue is the largest HTTP/2 stream count and window size
+// accepted by Envoy
+const maxHTTP2SettingValue = 1<<31 - 1
+
+// validateHTTP2Options checks the HTTP/2 settings against the limits of
+// Envoy.
+func validateHTTP2Options(protocol v1alpha1.UpstreamProtocol, opts *v1alpha1.HTTP2Options) error {
+	if protocol != v1alpha1.HTTP2 && protocol != v1alpha1.AutoProtocol {
+		return fmt.Errorf("http2 options require protocol %s or %s", v1alpha1.HTTP2, v1alpha1.AutoProtocol)
+	}
+	if opts.MaxConcurrentStreams > maxHTTP2SettingValue {
+		return fmt.Errorf("http2 maxConcurrentStreams must not exceed %d", maxHTTP2SettingValue)
+	}
+	for _, size := range []uint32{opts.InitialStreamWindowSize, opts.InitialConnectionWindowSize} {
+		if size != 0 && (size < 65535 || size > maxHTTP2SettingValue) {
+			return fmt.Errorf("http2 window size %d must be between 65535 and %d", size, maxHTTP2SettingValue)
+		}
+	}
+	if opts.KeepaliveInterval < 0 || opts.KeepaliveTimeout < 0 {
+		return fmt.Errorf("http2 keepalive interval and timeout must not be negative")
+	}
+	if opts.KeepaliveInterval != 0 && opts.KeepaliveTimeout < time.Millisecond {
+		return fmt.Errorf("http2 keepaliveInterval requires a keepaliveTimeout of at least 1ms")
+	}
+	if opts.KeepaliveInterval == 0 && opts.KeepaliveTimeout != 0 {
+		return fmt.Errorf("http2 keepaliveTimeout requires keepaliveInterval")
+	}
+	return nil
+}
+
+// ValidateDownstreamTLS checks the TLS settings of a listener.
+func ValidateDownstreamTLS(tls *v1alpha1.DownstreamTLS) error {
+	if tls.CertFile == "" || tls.KeyFile == "" {
+		return fmt.Errorf("tls certFile and keyFile are required")
+	}
+	if len(tls.VerifySubjectAltNames) > 0 && tls.CAFile == "" {
+		return fmt.Errorf("tls verifySubjectAltNames requires caFile")
+	}
+	if tls.RequireClientCertificate && tls.CAFile == "" && len(tls.VerifyCertificateHashes) == 0 {
+		return fmt.Errorf("tls requireClientCertificate requires caFile or verifyCertificateHashes")
+	}
+	for _, hash := range tls.VerifyCertificateHashes {
+		if b, err := hex.DecodeString(strings.Replace(hash, ":", "", -1)); err != nil || len(b) != sha256.Size {
+			return fmt.Errorf("tls certificate hash %s is not a hex encoded SHA-256", hash)
+		}
+	}
+	for _, v := range []string{tls.MinVersion, tls.MaxVersion} {
+		if _, ok := tlsVersions[v]; !ok {
+			return fmt.Errorf("unknown tls version %s", v)
+		}
+	}
+	if tls.MinVersion != "" && tls.MaxVersion != "" && tlsVersions[tls.MinVersion] > tlsVersions[tls.MaxVersion] {
+		return fmt.Errorf("tls minVersion must not be greater than maxVersion")
+	}
+	return nil
+}
+
+// ValidateListener checks that the filter chains of a listener can be told
+// apart and fit its protocol. Only the default chain may omit server names.
+// Chains matching on SNI must terminate TLS since there is no SNI without it,
+// unless the listener passes TLS through to the upstream.
+func ValidateListener(l Listener) error {
+	switch l.Protocol {
+	case v1alpha1.HTTP, v1alpha1.TCP, v1alpha1.TLSPassthrough:
+	default:
+		return fmt.Errorf("unknown protocol %s", l.Protocol)
+	}
+
+	// a present extAuthz block is enabled unless it sets enabled: false
+	if extAuthzEnabled(l.ExtAuthz) {
+		if l.Protocol != v1alpha1.HTTP {
+			return fmt.Errorf("extAuthz is only supported by http listeners")
+		}
+		if err := validateExtAuthz(l.ExtAuthz); err != nil {
+			return err
+		}
+	}
+
+	if rl := l.GlobalRateLimit; rl != nil {
+		if l.Protocol != v1alpha1.HTTP {
+			return fmt.Errorf("globalRateLimit is only supported by http listeners")
+		}
+		if rl.Domain == "" || rl.Cluster == "" {
+			return fmt.Errorf("globalRateLimit domain and cluster are required")
+		}
+		if rl.Timeout < 0 {
+			return fmt.Errorf("globalRateLimit timeout must not be negative")
+		}
+	}
+
+	for _, al := range l.AccessLogs {
+		if err := validateAccessLog(l.Protocol, al); err != nil {
+			return fmt.Errorf("access log: %w", err)
+		}
+	}
+
+	names := make(map[string]bool)
+	serverNames := make(map[string]string)
+	defaultChains := 0
+
+	for _, fc := range l.FilterChains {
+		if err := validateFilterChainTarget(l.Protocol, fc); err != nil {
+			if fc.Name == "" {
+				return fmt.Errorf("default filter chain: %w", err)
+			}
+			return fmt.Errorf("filter chain %s: %w", fc.Name, err)
+		}
+
+		if len(fc.ServerNames) == 0 {
+			defaultChains++
+			continue
+		}
+		if fc.Name == "" {
+			return fmt.Errorf("filter chain name is required")
+		}
+		if names[fc.Name] {
+			return fmt.Errorf("duplicate filter chain %s", fc.Name)
+		}
+		names[fc.Name] = true
+
+		if fc.TLS == nil && l.Protocol != v1alpha1.TLSPassthrough {
+			return fmt.Errorf("filter chain %s matches on server names but has no tls", fc.Name)
+		}
+		for _, sn := range fc.ServerNames {
+			// Envoy only supports a leading wildcard in server names
+			if sn == "" || strings.Contains(strings.TrimPrefix(sn, "*."), "*") {
+				return fmt.Errorf("filter chain %s: invalid server name %q", fc.Name, sn)
+			}
+			key := strings.ToLower(sn)
+			if owner, ok := serverNames[key]; ok {
+				return fmt.Errorf("server name %s of filter chain %s is already used by filter chain %s", sn, fc.Name, owner)
+			}
+			serverNames[key] = fc.Name
+		}
+	}
+	if defaultChains > 1 {
+		return fmt.Errorf("filter chains without server names overlap with the default chain")
+	}
+
+	return nil
+}
+
+func validateExtAuthz(cfg *v1alpha1.ExtAuthzFilter) error {
+	if cfg.Cluster == "" {
+		return fmt.Errorf("extAuthz cluster is required")
+	}
+	if cfg.Timeout < 0 {
+		return fmt.Errorf("extAuthz timeout must not be negative")
+	}
+	if cfg.IncludeBody != nil && cfg.IncludeBody.MaxBytes == 0 {
+		return fmt.Errorf("extAuthz includeBody requires a positive maxBytes")
+	}
+	return nil
+}
+
+func validateAccessLog(protocol v1alpha1.ListenerProtocol, al v1alpha1.AccessLog) error {
+	if countSet(al.File != nil, al.GRPC != nil) != 1 {
+		return fmt.Errorf("exactly one of file or grpc is required")
+	}
+	if f := al.File; f != nil {
+		if f.Path == "" {
+			return fmt.Errorf("file path is required")
+		}
+		if f.TextFormat != "" && len(f.JSONFormat) > 0 {
+			return fmt.Errorf("file textFormat and jsonFormat are mutually exclusive")
+		}
+	}
+	if al.GRPC != nil && al.GRPC.Cluster == "" {
+		return fmt.Errorf("grpc cluster is required")
+	}
+
+	filter := al.Filter
+	if filter == nil {
+		return nil
+	}
+	if countSet(filter.StatusCode != nil, filter.Duration != nil, filter.RuntimeSampling != nil) == 0 {
+		return fmt.Errorf("filter requires at least one of statusCode, duration or runtimeSampling")
+	}
+	if sc := filter.StatusCode; sc != nil {
+		if protocol != v1alpha1.HTTP {
+			return fmt.Errorf("statusCode filter is only supported by http listeners")
+		}
+		if _, ok := comparisonOps[sc.Op]; !ok {
+			return fmt.Errorf("unknown statusCode filter op %s", sc.Op)
+		}
+	}
+	if d := filter.Duration; d != nil {
+		if _, ok := comparisonOps[d.Op]; !ok {
+			return fmt.Errorf("unknown duration filter op %s", d.Op)
+		}
+		if d.Value < 0 {
+			return fmt.Errorf("duration filter value must not be negative")
+		}
+	}
+	if rs := filter.RuntimeSampling; rs != nil {
+		if rs.RuntimeKey == "" {
+			return fmt.Errorf("runtimeSampling runtimeKey is required")
+		}
+		if rs.Percent > 100 {
+			return fmt.Errorf("runtimeSampling percent must be between 0 and 100")
+		}
+	}
+	return nil
+}
+
+// validateFilterChainTarget checks that tcp and tls-passthrough chains
+// forward to exactly one of cluster or weightedClusters.
+func validateFilterChainTarget(protocol v1alpha1.ListenerProtocol, fc FilterChain) error {
+	if protocol == v1alpha1.HTTP {
+		if fc.Cluster != "" || len(fc.WeightedClusters) > 0 {
+			return fmt.Errorf("cluster and weightedClusters are only supported by tcp and tls-passthrough listeners, use routes instead")
+		}
+		return nil
+	}
+
+	if protocol == v1alpha1.TLSPassthrough && fc.TLS != nil {
+		return fmt.Errorf("tls-passthrough listeners cannot terminate tls")
+	}
+	if countSet(fc.Cluster != "", len(fc.WeightedClusters) > 0) != 1 {
+		return fmt.Errorf("exactly one of cluster or weightedClusters is required")
+	}
+	return validateWeightedClusters(fc.WeightedClusters)
+}
+
+// validateRegex checks an optional RE2 regex. Go's regexp package implements
+// the same syntax as the RE2 engine used by Envoy.
+func validateRegex(regex string) error {
+	if regex == "" {
+		return nil
+	}
+	if _, err := regexp.Compile(regex); err != nil {
+		return fmt.Errorf("invalid regex %q: %w", regex, err)
+	}
+	return nil
+}
+
+// countSet returns how many of the given mutually exclusive options are set.
+func countSet(options ...bool) int {
+	n := 0
+	for _, set := range options {
+		if set {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// RotatingFile is an io.Writer appending to a file that is rotated once it
+// grows beyond MaxBytes. The rotated files are renamed to Path.1 up to
+// Path.MaxBackups, with Path.1 being the most recent one.
+type RotatingFile struct {
+	Path       string
+	MaxBytes   int64
+	MaxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// OpenRotatingFile opens or creates the file at path for appending.
+func OpenRotatingFile(path string, maxBytes int64, maxBackups int) (*RotatingFile, error) {
+	r := &RotatingFile{
+		Path:       path,
+		MaxBytes:   maxBytes,
+		MaxBackups: maxBackups,
+	}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Write appends p to the current file, rotating it first when p would not fit.
+// A failed rotation is reported only after p has been written, unless no
+// file could be opened at all.
+func (r *RotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var rotateErr error
+	if r.file == nil {
+		// the file could not be reopened by the last rotation
+		if err := r.open(); err != nil {
+			return 0, err
+		}
+	} else if r.MaxBytes > 0 && r.size > 0 && r.size+int64(len(p)) > r.MaxBytes {
+		rotateErr = r.rotate()
+		if r.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	if err == nil {
+		err = rotateErr
+	}
+	return n, err
+}
+
+// Close closes the current file.
+func (r *RotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.file == nil {
+		return nil
+	}
+	return r.file.Close()
+}
+
+func (r *RotatingFile) open() error {
+	f, err := os.OpenFile(r.Path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+
+	r.file = f
+	r.size = info.Size()
+	return nil
+}
+
+// rotate shifts the backups and starts a new file. The file is reopened even
+// when closing or shifting fails so that logging carries on, in the current
+// file if it could not be shifted. r.file is nil when reopening fails.
+func (r *RotatingFile) rotate() error {
+	closeErr := r.file.Close()
+	r.file = nil
+	shiftErr := r.shiftBackups()
+
+	if err := r.open(); err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return shiftErr
+}
+
+func (r *RotatingFile) shiftBackups() error {
+	if r.MaxBackups == 0 {
+		return os.Remove(r.Path)
+	}
+
+	for i := r.MaxBackups - 1; i > 0; i-- {
+		backup := fmt.Sprintf("%s.%d", r.Path, i)
+		if _, err := os.Stat(backup); err != nil {
+			continue
+		}
+		if err := os.Rename(backup, fmt.Sprintf("%s.%d", r.Path, i+1)); err != nil {
+			return err
+		}
+	}
+	return os.Rename(r.Path, r.Path+".1")
+}
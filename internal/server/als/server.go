@@ -0,0 +1,128 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	accesslogservice "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v3"
+	"github.com/golang/protobuf/jsonpb"
+	"github.com/golang/protobuf/proto"
+	"google.golang.org/grpc"
+)
+
+const (
+	grpcMaxConcurrentStreams = 1000000
+)
+
+// Server receives access logs from Envoy and writes every entry as a JSON line
+// tagged with the name of the config the Envoy belongs to.
+type Server struct {
+	ClusterName string
+
+	mu        sync.Mutex
+	out       io.Writer
+	marshaler jsonpb.Marshaler
+}
+
+// entry is a single line of output. Entry holds the HTTPAccessLogEntry or
+// TCPAccessLogEntry as sent by Envoy.
+type entry struct {
+	Time     time.Time       `json:"time"`
+	Cluster  string          `json:"cluster"`
+	Node     string          `json:"node"`
+	LogName  string          `json:"logName"`
+	Protocol string          `json:"protocol"`
+	Entry    json.RawMessage `json:"entry"`
+}
+
+func (s *Server) StreamAccessLogs(stream accesslogservice.AccessLogService_StreamAccessLogsServer) error {
+	var node, logName string
+
+	for {
+		msg, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		// the identifier is only sent with the first message of a stream
+		if id := msg.Identifier; id != nil {
+			node = id.Node.GetId()
+			logName = id.LogName
+			log.Printf("access log stream %s opened by %s", logName, node)
+		}
+
+		switch logs := msg.LogEntries.(type) {
+		case *accesslogservice.StreamAccessLogsMessage_HttpLogs:
+			for _, e := range logs.HttpLogs.LogEntry {
+				s.write(node, logName, "http", e)
+			}
+		case *accesslogservice.StreamAccessLogsMessage_TcpLogs:
+			for _, e := range logs.TcpLogs.LogEntry {
+				s.write(node, logName, "tcp", e)
+			}
+		}
+	}
+}
+
+func (s *Server) write(node, logName, protocol string, pb proto.Message) {
+	raw, err := s.marshaler.MarshalToString(pb)
+	if err != nil {
+		log.Printf("unable to marshal access log entry: %s", err)
+		return
+	}
+
+	line, err := json.Marshal(entry{
+		Time:     time.Now().UTC(),
+		Cluster:  s.ClusterName,
+		Node:     node,
+		LogName:  logName,
+		Protocol: protocol,
+		Entry:    json.RawMessage(raw),
+	})
+	if err != nil {
+		log.Printf("unable to marshal access log entry: %s", err)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.out.Write(append(line, '\n')); err != nil {
+		log.Printf("unable to write access log entry: %s", err)
+	}
+}
+
+// NewServer returns a server writing the access logs of the Envoys using the
+// config named name to out.
+func NewServer(name string, out io.Writer) *Server {
+	return &Server{ClusterName: name, out: out}
+}
+
+func Run(server *Server, port uint) {
+	// gRPC golang library sets a very small upper bound for the number gRPC/h2
+	// streams over a single TCP connection. If a proxy multiplexes requests over
+	// a single connection to the management server, then it might lead to
+	// availability problems.
+	var grpcOptions []grpc.ServerOption
+	grpcOptions = append(grpcOptions, grpc.MaxConcurrentStreams(grpcMaxConcurrentStreams))
+	grpcServer := grpc.NewServer(grpcOptions...)
+
+	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	accesslogservice.RegisterAccessLogServiceServer(grpcServer, server)
+
+	log.Printf("access log server listening on %d\n", port)
+	if err = grpcServer.Serve(lis); err != nil {
+		log.Println(err)
+	}
+}
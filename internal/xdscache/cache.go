@@ -1,6 +1,9 @@
 package xdscache
 
 import (
+	"fmt"
+	"path/filepath"
+
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	"github.com/weinong/envoy-control-plane/apis/v1alpha1"
 	"github.com/weinong/envoy-control-plane/internal/resources"
@@ -8,10 +11,26 @@ import (
 
 type XDSCache struct {
 	Listeners map[string]resources.Listener
-	Routes    map[string]resources.Route
-	Clusters  map[string]resources.Cluster
-	Endpoints map[string]resources.Endpoint
-	RouteKey  string
+	// Routes holds the route configurations keyed by name
+	Routes   map[string]resources.RouteConfig
+	Clusters map[string]resources.Cluster
+	// Endpoints holds the endpoints of EDS clusters keyed by cluster name
+	Endpoints map[string][]resources.Endpoint
+	Secrets   map[string]resources.Secret
+	// SecretFiles holds the paths of the files the secrets were read from
+	SecretFiles map[string]bool
+}
+
+// NewXDSCache returns an empty XDSCache ready to be populated from a config file.
+func NewXDSCache() XDSCache {
+	return XDSCache{
+		Listeners:   make(map[string]resources.Listener),
+		Clusters:    make(map[string]resources.Cluster),
+		Routes:      make(map[string]resources.RouteConfig),
+		Endpoints:   make(map[string][]resources.Endpoint),
+		Secrets:     make(map[string]resources.Secret),
+		SecretFiles: make(map[string]bool),
+	}
 }
 
 func (xds *XDSCache) ClusterContents() []types.Resource {
@@ -24,59 +43,394 @@ func (xds *XDSCache) ClusterContents() []types.Resource {
 	return r
 }
 
+func (xds *XDSCache) EndpointContents() []types.Resource {
+	var r []types.Resource
+
+	for name, eps := range xds.Endpoints {
+		r = append(r, resources.MakeEndpoint(name, eps))
+	}
+
+	return r
+}
+
+func (xds *XDSCache) SecretContents() []types.Resource {
+	var r []types.Resource
+
+	for _, s := range xds.Secrets {
+		r = append(r, s.MakeSecret())
+	}
+
+	return r
+}
+
 func (xds *XDSCache) RouteContents() []types.Resource {
+	var r []types.Resource
 
-	var routesArray []resources.Route
-	for _, r := range xds.Routes {
-		routesArray = append(routesArray, r)
+	for _, rc := range xds.Routes {
+		r = append(r, resources.MakeRoute(rc))
 	}
 
-	return []types.Resource{resources.MakeRoute(xds.RouteKey, routesArray)}
+	return r
 }
 
 func (xds *XDSCache) ListenerContents() []types.Resource {
 	var r []types.Resource
 
 	for _, l := range xds.Listeners {
-		r = append(r, resources.MakeHTTPListener(l.Name, l.RouteNames[0], l.Address, l.Port, l.CertFile, l.KeyFile))
+		r = append(r, resources.MakeListener(l))
 	}
 
 	return r
 }
 
-func (xds *XDSCache) AddListener(name string, routeNames []string, address string, port uint32, certFile, keyFile string) {
-	xds.Listeners[name] = resources.Listener{
-		Name:       name,
-		Address:    address,
-		Port:       port,
-		RouteNames: routeNames,
-		CertFile:   certFile,
-		KeyFile:    keyFile,
+func (xds *XDSCache) AddListener(listener v1alpha1.Listener) error {
+	if _, ok := xds.Listeners[listener.Name]; ok {
+		return fmt.Errorf("duplicate listener %s", listener.Name)
+	}
+
+	l := resources.Listener{
+		Name:            listener.Name,
+		Address:         listener.Address,
+		Port:            listener.Port,
+		Protocol:        listener.Protocol,
+		ExtAuthz:        listener.ExtAuthz,
+		GlobalRateLimit: listener.GlobalRateLimit,
+		AccessLogs:      listener.AccessLogs,
+	}
+	if l.Protocol == "" {
+		l.Protocol = v1alpha1.HTTP
+	}
+
+	tls := listener.TLS
+	if listener.CertFile != "" || listener.KeyFile != "" {
+		if tls != nil {
+			return fmt.Errorf("listener %s: certFile and keyFile cannot be combined with tls", listener.Name)
+		}
+		tls = &v1alpha1.DownstreamTLS{CertFile: listener.CertFile, KeyFile: listener.KeyFile}
+	}
+
+	// the routes, upstream clusters and TLS declared on the listener itself
+	// make up the default chain, whose route configuration is named after
+	// the listener
+	defaultChain := v1alpha1.FilterChain{
+		TLS:                tls,
+		Routes:             listener.Routes,
+		VirtualHosts:       listener.VirtualHosts,
+		HeaderManipulation: listener.HeaderManipulation,
+		Cluster:            listener.Cluster,
+		WeightedClusters:   listener.WeightedClusters,
+	}
+	hasDefaultChain := len(listener.Routes) > 0 || len(listener.VirtualHosts) > 0 || tls != nil ||
+		listener.Cluster != "" || len(listener.WeightedClusters) > 0 || hasHeaderManipulation(listener.HeaderManipulation)
+	if hasDefaultChain || len(listener.FilterChains) == 0 {
+		fc, err := xds.addFilterChain(listener.Name, l.Protocol, defaultChain)
+		if err != nil {
+			return err
+		}
+		l.FilterChains = append(l.FilterChains, fc)
+	}
+	for _, chain := range listener.FilterChains {
+		fc, err := xds.addFilterChain(listener.Name+"/"+chain.Name, l.Protocol, chain)
+		if err != nil {
+			return err
+		}
+		l.FilterChains = append(l.FilterChains, fc)
+	}
+	if err := resources.ValidateListener(l); err != nil {
+		return err
+	}
+	// route rate limits are only enforced by the filter of globalRateLimit
+	if l.GlobalRateLimit == nil {
+		for _, fc := range l.FilterChains {
+			if fc.RouteConfigName != "" && usesRateLimits(xds.Routes[fc.RouteConfigName].VirtualHosts) {
+				return fmt.Errorf("route configuration %s: rateLimits require a listener globalRateLimit", fc.RouteConfigName)
+			}
+		}
+	}
+
+	xds.Listeners[listener.Name] = l
+
+	return nil
+}
+
+// addFilterChain adds the route configuration and secrets of a filter chain,
+// all named after owner. Only http filter chains have a route configuration.
+func (xds *XDSCache) addFilterChain(owner string, protocol v1alpha1.ListenerProtocol, chain v1alpha1.FilterChain) (resources.FilterChain, error) {
+	fc := resources.FilterChain{
+		Name:             chain.Name,
+		ServerNames:      chain.ServerNames,
+		Cluster:          chain.Cluster,
+		WeightedClusters: chain.WeightedClusters,
+		TLS:              chain.TLS,
+	}
+
+	if protocol == v1alpha1.HTTP {
+		// owner is listener/chain, which a listener named like that would
+		// collide with
+		if _, ok := xds.Routes[owner]; ok {
+			return fc, fmt.Errorf("duplicate route configuration %s", owner)
+		}
+		fc.RouteConfigName = owner
+	} else if len(chain.Routes) > 0 || len(chain.VirtualHosts) > 0 || hasHeaderManipulation(chain.HeaderManipulation) {
+		return fc, fmt.Errorf("filter chain %s: %s listeners cannot have routes or headers", owner, protocol)
+	}
+
+	var virtualHosts []resources.VirtualHost
+	if len(chain.Routes) > 0 {
+		virtualHosts = append(virtualHosts, resources.VirtualHost{
+			Name:    "local_service",
+			Domains: []string{"*"},
+			Routes:  makeRoutes(chain.Routes),
+		})
+	}
+	for _, vh := range chain.VirtualHosts {
+		virtualHosts = append(virtualHosts, resources.VirtualHost{
+			Name:               vh.Name,
+			Domains:            vh.Domains,
+			Routes:             makeRoutes(vh.Routes),
+			Cors:               vh.Cors,
+			HeaderManipulation: vh.HeaderManipulation,
+		})
+	}
+	rc := resources.RouteConfig{
+		Name:               owner,
+		VirtualHosts:       virtualHosts,
+		HeaderManipulation: chain.HeaderManipulation,
+	}
+	if err := resources.ValidateRouteConfig(rc); err != nil {
+		return fc, fmt.Errorf("route configuration %s: %w", owner, err)
+	}
+
+	if tls := chain.TLS; tls != nil {
+		if err := resources.ValidateDownstreamTLS(tls); err != nil {
+			return fc, err
+		}
+		fc.CertSecret = secretName("listener", owner, "cert")
+		if err := xds.addCertificateSecret(fc.CertSecret, tls.CertFile, tls.KeyFile); err != nil {
+			return fc, err
+		}
+		if tls.CAFile != "" {
+			fc.CASecret = secretName("listener", owner, "ca")
+			if err := xds.addValidationSecret(fc.CASecret, tls.CAFile); err != nil {
+				return fc, err
+			}
+		}
+	}
+
+	if fc.RouteConfigName != "" {
+		xds.Routes[fc.RouteConfigName] = rc
+		fc.CORS = usesCors(virtualHosts)
+		fc.LocalRateLimit = usesLocalRateLimit(virtualHosts)
+		fc.Fault = usesFault(virtualHosts)
+	}
+
+	return fc, nil
+}
+
+// hasHeaderManipulation tells whether any header is added or removed.
+func hasHeaderManipulation(h v1alpha1.HeaderManipulation) bool {
+	return len(h.RequestHeadersToAdd) > 0 || len(h.RequestHeadersToRemove) > 0 ||
+		len(h.ResponseHeadersToAdd) > 0 || len(h.ResponseHeadersToRemove) > 0
+}
+
+// usesCors tells whether any virtual host or route has a CORS policy.
+func usesCors(virtualHosts []resources.VirtualHost) bool {
+	for _, vh := range virtualHosts {
+		if vh.Cors != nil {
+			return true
+		}
+		for _, r := range vh.Routes {
+			if r.Cors != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// usesLocalRateLimit tells whether any route has a local rate limit.
+func usesLocalRateLimit(virtualHosts []resources.VirtualHost) bool {
+	for _, vh := range virtualHosts {
+		for _, r := range vh.Routes {
+			if r.LocalRateLimit != nil {
+				return true
+			}
+		}
 	}
+	return false
 }
 
-func (xds *XDSCache) AddRoute(name, prefix string, header string, hostRewrite string) {
-	xds.Routes[name] = resources.Route{
-		Name:        name,
-		Prefix:      prefix,
-		Header:      header,
-		HostRewrite: hostRewrite,
+// usesRateLimits tells whether any route has global rate limit actions.
+func usesRateLimits(virtualHosts []resources.VirtualHost) bool {
+	for _, vh := range virtualHosts {
+		for _, r := range vh.Routes {
+			if len(r.RateLimits) > 0 {
+				return true
+			}
+		}
 	}
+	return false
 }
 
-func (xds *XDSCache) AddCluster(cluster v1alpha1.Cluster) {
+// usesFault tells whether any route injects faults.
+func usesFault(virtualHosts []resources.VirtualHost) bool {
+	for _, vh := range virtualHosts {
+		for _, r := range vh.Routes {
+			if r.Fault != nil {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func makeRoutes(routes []v1alpha1.Route) []resources.Route {
+	var r []resources.Route
+
+	for _, v := range routes {
+		r = append(r, resources.Route{
+			Name:                  v.Name,
+			Prefix:                v.Prefix,
+			Path:                  v.Path,
+			SafeRegex:             v.SafeRegex,
+			Headers:               v.Headers,
+			QueryParameters:       v.QueryParameters,
+			HostRewrite:           v.HostRewrite,
+			Cluster:               v.Cluster,
+			WeightedClusters:      v.WeightedClusters,
+			ClusterHeader:         v.ClusterHeader,
+			Timeout:               v.Timeout,
+			IdleTimeout:           v.IdleTimeout,
+			RetryPolicy:           v.RetryPolicy,
+			HashPolicies:          v.HashPolicies,
+			DisableExtAuthz:       v.DisableExtAuthz,
+			Cors:                  v.Cors,
+			LocalRateLimit:        v.LocalRateLimit,
+			RateLimits:            v.RateLimits,
+			Fault:                 v.Fault,
+			RequestMirrorPolicies: v.RequestMirrorPolicies,
+			HeaderManipulation:    v.HeaderManipulation,
+			PrefixRewrite:         v.PrefixRewrite,
+			RegexRewrite:          v.RegexRewrite,
+			Redirect:              v.Redirect,
+			DirectResponse:        v.DirectResponse,
+		})
+	}
+
+	return r
+}
+
+func (xds *XDSCache) AddCluster(cluster v1alpha1.Cluster) error {
 	c := resources.Cluster{
-		Name:          cluster.Name,
-		IsHTTPS:       cluster.IsHTTPS,
-		DiscoveryType: string(cluster.DiscoveryType),
+		Name:             cluster.Name,
+		TLS:              cluster.TLS,
+		DiscoveryType:    string(cluster.DiscoveryType),
+		ConnectTimeout:   cluster.ConnectTimeout,
+		RetryBudget:      cluster.RetryBudget,
+		LbPolicy:         cluster.LbPolicy,
+		LeastRequest:     cluster.LeastRequestConfig,
+		RingHash:         cluster.RingHashConfig,
+		Maglev:           cluster.MaglevConfig,
+		HealthCheck:      cluster.HealthCheck,
+		CircuitBreakers:  cluster.CircuitBreakers,
+		OutlierDetection: cluster.OutlierDetection,
+		Protocol:         cluster.Protocol,
+		HTTP2:            cluster.HTTP2,
+		TCPKeepalive:     cluster.TCPKeepalive,
+		ZoneAwareRouting: cluster.ZoneAwareRouting,
+	}
+	if c.TLS == nil && cluster.IsHTTPS {
+		c.TLS = &v1alpha1.UpstreamTLS{}
+	}
+	if c.Protocol == "" {
+		c.Protocol = v1alpha1.HTTP1
+		if cluster.HealthCheck != nil && cluster.HealthCheck.GRPC != nil {
+			c.Protocol = v1alpha1.HTTP2
+		}
 	}
 
+	var endpoints []resources.Endpoint
 	for _, v := range cluster.Endpoints {
-		c.Endpoints = append(c.Endpoints, resources.Endpoint{
+		endpoints = append(endpoints, resources.Endpoint{
 			UpstreamHost: v.Address,
 			UpstreamPort: v.Port,
+			Region:       v.Region,
+			Zone:         v.Zone,
+			SubZone:      v.SubZone,
+			Priority:     v.Priority,
+			Weight:       v.Weight,
+			HealthStatus: v.HealthStatus,
 		})
 	}
 
+	if err := resources.ValidateCluster(c); err != nil {
+		return err
+	}
+	if err := resources.ValidateEndpoints(endpoints); err != nil {
+		return fmt.Errorf("cluster %s: %w", cluster.Name, err)
+	}
+
+	if tls := c.TLS; tls != nil {
+		if tls.CertFile != "" {
+			c.ClientCertSecret = secretName("cluster", cluster.Name, "cert")
+			if err := xds.addCertificateSecret(c.ClientCertSecret, tls.CertFile, tls.KeyFile); err != nil {
+				return err
+			}
+		}
+		if tls.CAFile != "" {
+			c.CASecret = secretName("cluster", cluster.Name, "ca")
+			if err := xds.addValidationSecret(c.CASecret, tls.CAFile); err != nil {
+				return err
+			}
+		}
+	}
+
+	// EDS endpoints are served as their own resource so that endpoint churn
+	// does not change the cluster itself
+	if cluster.DiscoveryType == v1alpha1.EDS {
+		xds.Endpoints[cluster.Name] = endpoints
+	} else {
+		c.Endpoints = endpoints
+	}
+
 	xds.Clusters[cluster.Name] = c
+
+	return nil
+}
+
+// secretName names the SDS secret used by a listener or cluster, e.g.
+// listener/listener1/cert, listener/listener1/api/cert or cluster/cluster2/ca.
+func secretName(kind, owner, role string) string {
+	return fmt.Sprintf("%s/%s/%s", kind, owner, role)
+}
+
+func (xds *XDSCache) addCertificateSecret(name, certFile, keyFile string) error {
+	if _, ok := xds.Secrets[name]; ok {
+		return fmt.Errorf("duplicate secret %s", name)
+	}
+	secret, err := resources.NewCertificateSecret(name, certFile, keyFile)
+	if err != nil {
+		return fmt.Errorf("secret %s: %w", name, err)
+	}
+
+	xds.Secrets[name] = secret
+	xds.SecretFiles[filepath.Clean(certFile)] = true
+	xds.SecretFiles[filepath.Clean(keyFile)] = true
+
+	return nil
+}
+
+func (xds *XDSCache) addValidationSecret(name, caFile string) error {
+	if _, ok := xds.Secrets[name]; ok {
+		return fmt.Errorf("duplicate secret %s", name)
+	}
+	secret, err := resources.NewValidationSecret(name, caFile)
+	if err != nil {
+		return fmt.Errorf("secret %s: %w", name, err)
+	}
+
+	xds.Secrets[name] = secret
+	xds.SecretFiles[filepath.Clean(caFile)] = true
+
+	return nil
 }